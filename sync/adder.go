@@ -0,0 +1,74 @@
+package sync
+
+import (
+	"math/bits"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// LongAdder is a counter for hot metrics. It spreads additions across padded cells,
+// so concurrent writers rarely contend on the same cache line, at the cost of a slower Sum.
+// The zero value is ready to use.
+type LongAdder struct {
+	once  sync.Once
+	mask  uint32
+	cells []adderCell
+}
+
+type adderCell struct {
+	atomic.Int64
+	_ [56]byte // pads the cell to a cache line
+}
+
+func (a *LongAdder) init() {
+	a.once.Do(func() {
+		n := 1 << bits.Len(uint(runtime.GOMAXPROCS(0)-1))
+		a.mask = uint32(n - 1)
+		a.cells = make([]adderCell, n)
+	})
+}
+
+// Add adds delta to the counter.
+func (a *LongAdder) Add(delta int64) {
+	a.init()
+	a.cells[rand.Uint32()&a.mask].Add(delta)
+}
+
+// Inc increments the counter.
+func (a *LongAdder) Inc() {
+	a.Add(1)
+}
+
+// Dec decrements the counter.
+func (a *LongAdder) Dec() {
+	a.Add(-1)
+}
+
+// Sum returns the sum of the counter. It is not an atomic snapshot:
+// additions made concurrently with Sum may or may not be counted.
+func (a *LongAdder) Sum() int64 {
+	a.init()
+	var sum int64
+	for i := range a.cells {
+		sum += a.cells[i].Load()
+	}
+	return sum
+}
+
+// Reset sets the counter to zero. Additions made concurrently with Reset may or may not be dropped.
+func (a *LongAdder) Reset() {
+	a.SumAndReset()
+}
+
+// SumAndReset returns the sum of the counter and sets it to zero.
+// Unlike calling Sum and Reset, each addition is either counted in the returned sum or kept in the counter.
+func (a *LongAdder) SumAndReset() int64 {
+	a.init()
+	var sum int64
+	for i := range a.cells {
+		sum += a.cells[i].Swap(0)
+	}
+	return sum
+}
@@ -0,0 +1,58 @@
+package sync
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func HammerLongAdder(a *LongAdder, loops int) {
+	for i := 0; i < loops; i++ {
+		a.Inc()
+		a.Add(2)
+		a.Dec()
+	}
+}
+
+func TestLongAdder(t *testing.T) {
+	t.Parallel()
+
+	n := runtime.GOMAXPROCS(0)
+	loops := 10000 / n
+	var a LongAdder
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			HammerLongAdder(&a, loops)
+		}()
+	}
+	wg.Wait()
+
+	want := int64(2 * n * loops)
+	if got := a.Sum(); got != want {
+		t.Errorf("Sum: got %d, want %d", got, want)
+	}
+	if got := a.SumAndReset(); got != want {
+		t.Errorf("SumAndReset: got %d, want %d", got, want)
+	}
+	if got := a.Sum(); got != 0 {
+		t.Errorf("Sum after reset: got %d, want 0", got)
+	}
+
+	a.Add(5)
+	a.Reset()
+	if got := a.Sum(); got != 0 {
+		t.Errorf("Sum after Reset: got %d, want 0", got)
+	}
+}
+
+func BenchmarkLongAdder(b *testing.B) {
+	var a LongAdder
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			a.Inc()
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBatcherClosed is the error of items added to a closed Batcher.
+var ErrBatcherClosed = errors.New("batcher is closed")
+
+// BatcherSettings configures Batcher.
+type BatcherSettings struct {
+	// MaxSize is the number of items that triggers flushing the batch. Zero means no limit.
+	MaxSize int
+	// MaxDelay is the time since the first item of the batch, after which the batch is flushed. Zero means no limit.
+	MaxDelay time.Duration
+	// Semaphore bounds the number of concurrent flushes. By default, batches are flushed one by one.
+	Semaphore *Semaphore
+}
+
+// Batcher collects items from concurrent Add calls into batches, and flushes a batch
+// when it reaches MaxSize or MaxDelay, whichever comes first. Unlike slice.Batch, it batches a stream of items.
+// Flushes start in the order the batches are formed. Background goroutines run until Close is called.
+type Batcher[T any] struct {
+	ctx      context.Context
+	settings BatcherSettings
+	flush    func(ctx context.Context, batch []T) error
+	clock    Clock
+	timer    Timer
+	stop     chan struct{}
+	ready    chan struct{}
+	wg       sync.WaitGroup
+
+	mu     sync.Mutex
+	closed bool
+	batch  *pendingBatch[T]
+	queue  []*pendingBatch[T]
+}
+
+type pendingBatch[T any] struct {
+	items    []T
+	deadline time.Time
+	result   *Future[struct{}]
+}
+
+// BatcherOption configures a Batcher.
+type BatcherOption interface {
+	applyBatcher(clock *Clock)
+}
+
+func (o ClockOption) applyBatcher(c *Clock) { *c = o.clock }
+
+// NewBatcher creates a new Batcher that flushes batches by the function with ctx. WithClock replaces the real time.
+func NewBatcher[T any](ctx context.Context, settings BatcherSettings, flush func(ctx context.Context, batch []T) error, opts ...BatcherOption) *Batcher[T] {
+	var clock Clock = RealClock{}
+	for _, opt := range opts {
+		opt.applyBatcher(&clock)
+	}
+	if settings.Semaphore == nil {
+		settings.Semaphore = NewSemaphore(1)
+	}
+	b := &Batcher[T]{
+		ctx:      ctx,
+		settings: settings,
+		flush:    flush,
+		clock:    clock,
+		stop:     make(chan struct{}),
+		ready:    make(chan struct{}, 1),
+	}
+	b.timer = b.clock.NewTimer(settings.MaxDelay)
+	b.timer.Stop()
+	go b.loop()
+	go b.dispatch()
+	return b
+}
+
+// Add adds the item to the current batch and returns the future of the outcome of flushing that batch.
+func (b *Batcher[T]) Add(item T) *Future[struct{}] {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return Rejected[struct{}](ErrBatcherClosed)
+	}
+	if b.batch == nil {
+		b.batch = &pendingBatch[T]{result: newFuture[struct{}]()}
+		if b.settings.MaxDelay > 0 {
+			b.batch.deadline = b.clock.Now().Add(b.settings.MaxDelay)
+			b.timer.Reset(b.settings.MaxDelay)
+		}
+	}
+	batch := b.batch
+	batch.items = append(batch.items, item)
+	if b.settings.MaxSize > 0 && len(batch.items) >= b.settings.MaxSize {
+		b.cut()
+	}
+	return batch.result
+}
+
+// Flush starts flushing the current batch without waiting for MaxSize or MaxDelay.
+func (b *Batcher[T]) Flush() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.cut()
+}
+
+// Close flushes the current batch, rejects subsequent items and waits until all flushes complete.
+// If ctx is done first, it returns ctx.Err(), and the remaining flushes go on in the background.
+func (b *Batcher[T]) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		b.cut()
+		b.notify()
+		close(b.stop)
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Batcher[T]) loop() {
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-b.timer.C():
+			b.mu.Lock()
+			if b.batch != nil && !b.clock.Now().Before(b.batch.deadline) {
+				b.cut()
+			}
+			b.mu.Unlock()
+		}
+	}
+}
+
+// dispatch starts flushing the queued batches in order as soon as the semaphore allows.
+func (b *Batcher[T]) dispatch() {
+	for {
+		b.mu.Lock()
+		if len(b.queue) == 0 {
+			closed := b.closed
+			b.mu.Unlock()
+			if closed {
+				return
+			}
+			<-b.ready
+			continue
+		}
+		batch := b.queue[0]
+		b.queue[0] = nil
+		b.queue = b.queue[1:]
+		b.mu.Unlock()
+
+		if err := b.settings.Semaphore.Acquire(b.ctx); err != nil {
+			batch.result.complete(struct{}{}, err)
+			b.wg.Done()
+			continue
+		}
+		go func() {
+			defer b.wg.Done()
+			defer b.settings.Semaphore.Release()
+
+			batch.result.complete(protect(func() (struct{}, error) {
+				return struct{}{}, b.flush(b.ctx, batch.items)
+			}))
+		}()
+	}
+}
+
+// cut queues the current batch for flushing. It must be called with the lock held.
+func (b *Batcher[T]) cut() {
+	batch := b.batch
+	if batch == nil {
+		return
+	}
+	b.batch = nil
+	b.timer.Stop()
+
+	b.wg.Add(1)
+	b.queue = append(b.queue, batch)
+	b.notify()
+}
+
+// notify wakes the dispatcher.
+func (b *Batcher[T]) notify() {
+	select {
+	case b.ready <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,133 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatcherMaxSize(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var batches [][]int
+	b := NewBatcher(context.Background(), BatcherSettings{MaxSize: 3}, func(_ context.Context, batch []int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, batch)
+		return nil
+	})
+
+	var futures []*Future[struct{}]
+	for i := range 7 {
+		futures = append(futures, b.Add(i))
+	}
+	for _, f := range futures[:6] {
+		if _, err := f.Await(context.Background()); err != nil {
+			t.Errorf("Await returns error %v", err)
+		}
+	}
+	select {
+	case <-futures[6].Done():
+		t.Error("incomplete batch was flushed")
+	default:
+	}
+
+	if err := b.Close(context.Background()); err != nil {
+		t.Errorf("Close returns error %v", err)
+	}
+	if _, err := futures[6].Await(context.Background()); err != nil {
+		t.Errorf("Await returns error %v", err)
+	}
+
+	want := [][]int{{0, 1, 2}, {3, 4, 5}, {6}}
+	if !slices.EqualFunc(batches, want, slices.Equal) {
+		t.Errorf("batches: got %v, want %v", batches, want)
+	}
+
+	if _, err := b.Add(7).Await(context.Background()); err != ErrBatcherClosed {
+		t.Errorf("Add after Close error = %v; want %v", err, ErrBatcherClosed)
+	}
+}
+
+func TestBatcherMaxDelay(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	flushed := make(chan []int, 10)
+	b := NewBatcher(context.Background(), BatcherSettings{MaxSize: 10, MaxDelay: time.Second}, func(_ context.Context, batch []int) error {
+		flushed <- batch
+		return nil
+	}, WithClock(clock))
+	defer b.Close(context.Background())
+
+	f := b.Add(1)
+	clock.Advance(500 * time.Millisecond)
+	b.Add(2)
+	select {
+	case batch := <-flushed:
+		t.Fatalf("batch %v was flushed before MaxDelay", batch)
+	default:
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	if _, err := f.Await(context.Background()); err != nil {
+		t.Errorf("Await returns error %v", err)
+	}
+	if got := <-flushed; !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("batch: got %v, want [1 2]", got)
+	}
+}
+
+func TestBatcherError(t *testing.T) {
+	t.Parallel()
+
+	expected := errors.New("failed")
+	b := NewBatcher(context.Background(), BatcherSettings{MaxSize: 2}, func(_ context.Context, batch []int) error {
+		if batch[0] == 0 {
+			return expected
+		}
+		return nil
+	})
+	defer b.Close(context.Background())
+
+	f1, f2, f3 := b.Add(0), b.Add(1), b.Add(2)
+	b.Flush()
+	for _, f := range []*Future[struct{}]{f1, f2} {
+		if _, err := f.Await(context.Background()); err != expected {
+			t.Errorf("Await error = %v; want %v", err, expected)
+		}
+	}
+	if _, err := f3.Await(context.Background()); err != nil {
+		t.Errorf("Await returns error %v", err)
+	}
+}
+
+func TestBatcherConcurrency(t *testing.T) {
+	t.Parallel()
+
+	const limit = 2
+	var meter concurrencyMeter
+	b := NewBatcher(context.Background(), BatcherSettings{MaxSize: 1, Semaphore: NewSemaphore(limit)}, func(context.Context, []int) error {
+		meter.run()
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.Add(i).Await(context.Background())
+		}()
+	}
+	wg.Wait()
+	b.Close(context.Background())
+
+	if got := meter.max.Load(); got > limit {
+		t.Errorf("max concurrent flushes = %d; want at most %d", got, limit)
+	}
+}
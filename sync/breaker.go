@@ -0,0 +1,290 @@
+// The state machine of CircuitBreaker is derived from github.com/sony/gobreaker.
+//
+// Copyright 2015 Sony Corporation.
+// Use of this source code is governed by the MIT license
+// that can be found at https://github.com/sony/gobreaker/blob/master/LICENSE.
+
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is returned by CircuitBreaker.Execute while the breaker is open
+// or the half-open breaker has reached the limit of probe requests.
+var ErrBreakerOpen = errors.New("circuit breaker is open")
+
+// BreakerState is a state of CircuitBreaker.
+type BreakerState int
+
+const (
+	// StateClosed lets requests through and counts their outcomes.
+	StateClosed BreakerState = iota
+	// StateOpen rejects requests with ErrBreakerOpen.
+	StateOpen
+	// StateHalfOpen lets a limited number of probe requests through to check whether the dependency has recovered.
+	StateHalfOpen
+)
+
+// String implements the fmt.Stringer interface.
+func (s BreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerCounts holds the numbers of requests and their outcomes.
+// The counts are cleared on every state change and every interval in the closed state.
+type BreakerCounts struct {
+	Requests             int
+	Successes            int
+	Failures             int
+	ConsecutiveSuccesses int
+	ConsecutiveFailures  int
+}
+
+func (c *BreakerCounts) success() {
+	c.Successes++
+	c.ConsecutiveSuccesses++
+	c.ConsecutiveFailures = 0
+}
+
+func (c *BreakerCounts) failure() {
+	c.Failures++
+	c.ConsecutiveFailures++
+	c.ConsecutiveSuccesses = 0
+}
+
+// TripPolicy reports whether the closed breaker must open after a failure.
+type TripPolicy func(counts BreakerCounts) bool
+
+// ConsecutiveFailures returns the TripPolicy that trips the breaker after n consecutive failures.
+func ConsecutiveFailures(n int) TripPolicy {
+	return func(c BreakerCounts) bool {
+		return c.ConsecutiveFailures >= n
+	}
+}
+
+// FailureRatio returns the TripPolicy that trips the breaker when the ratio of failures reaches ratio,
+// provided that there were at least minRequests requests.
+func FailureRatio(ratio float64, minRequests int) TripPolicy {
+	return func(c BreakerCounts) bool {
+		return c.Requests >= minRequests && float64(c.Failures)/float64(c.Requests) >= ratio
+	}
+}
+
+// BreakerSettings configures CircuitBreaker. Zero fields get the default values.
+type BreakerSettings struct {
+	// Trip decides when the closed breaker opens. By default, it is ConsecutiveFailures(5).
+	Trip TripPolicy
+	// OpenTimeout is the duration of the open state, after which the breaker becomes half-open. By default, it is 60 seconds.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of probe requests in the half-open state.
+	// If all of them succeed, the breaker closes. By default, it is 1.
+	HalfOpenRequests int
+	// Interval is the period of clearing counts in the closed state. By default, the counts are not cleared.
+	Interval time.Duration
+	// IsFailure reports whether the error is a failure. By default, any non-nil error is a failure.
+	IsFailure func(err error) bool
+	// OnStateChange is called on every state change.
+	OnStateChange func(from, to BreakerState)
+}
+
+// CircuitBreaker stops calling a failing dependency for a while, so that calls fail fast instead of waiting for it.
+type CircuitBreaker[T any] struct {
+	settings BreakerSettings
+	clock    Clock
+
+	mu         sync.Mutex
+	state      BreakerState
+	generation uint64
+	counts     BreakerCounts
+	expiry     time.Time
+}
+
+type breakerTransition struct {
+	from, to BreakerState
+}
+
+// BreakerOption configures a CircuitBreaker.
+type BreakerOption interface {
+	applyBreaker(clock *Clock)
+}
+
+func (o ClockOption) applyBreaker(c *Clock) { *c = o.clock }
+
+// NewCircuitBreaker creates a new closed CircuitBreaker. WithClock replaces the real time.
+func NewCircuitBreaker[T any](settings BreakerSettings, opts ...BreakerOption) *CircuitBreaker[T] {
+	var clock Clock = RealClock{}
+	for _, opt := range opts {
+		opt.applyBreaker(&clock)
+	}
+	if settings.Trip == nil {
+		settings.Trip = ConsecutiveFailures(5)
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = 60 * time.Second
+	}
+	if settings.HalfOpenRequests <= 0 {
+		settings.HalfOpenRequests = 1
+	}
+	if settings.IsFailure == nil {
+		settings.IsFailure = func(err error) bool { return err != nil }
+	}
+
+	b := &CircuitBreaker[T]{settings: settings, clock: clock}
+	b.newGeneration(b.clock.Now())
+	return b
+}
+
+// Execute calls fn if the breaker lets the request through, otherwise it returns ErrBreakerOpen.
+// The outcome of fn is counted to decide on the state. A panic in fn is counted as a failure and re-panicked.
+func (b *CircuitBreaker[T]) Execute(ctx context.Context, fn func(ctx context.Context) (T, error)) (v T, err error) {
+	if err = ctx.Err(); err != nil {
+		return v, err
+	}
+	generation, err := b.before()
+	if err != nil {
+		return v, err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			b.after(generation, false)
+			panic(r)
+		}
+	}()
+
+	v, err = fn(ctx)
+	b.after(generation, !b.settings.IsFailure(err))
+	return v, err
+}
+
+// State returns the current state of the breaker.
+func (b *CircuitBreaker[T]) State() BreakerState {
+	b.mu.Lock()
+	state, transitions := b.currentState(b.clock.Now(), nil)
+	b.mu.Unlock()
+
+	b.notify(transitions)
+	return state
+}
+
+// Counts returns the counts of the current state.
+func (b *CircuitBreaker[T]) Counts() BreakerCounts {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.counts
+}
+
+func (b *CircuitBreaker[T]) before() (uint64, error) {
+	b.mu.Lock()
+	now := b.clock.Now()
+	state, transitions := b.currentState(now, nil)
+	generation := b.generation
+
+	var err error
+	switch {
+	case state == StateOpen:
+		err = ErrBreakerOpen
+	case state == StateHalfOpen && b.counts.Requests >= b.settings.HalfOpenRequests:
+		err = ErrBreakerOpen
+	default:
+		b.counts.Requests++
+	}
+	b.mu.Unlock()
+
+	b.notify(transitions)
+	return generation, err
+}
+
+func (b *CircuitBreaker[T]) after(generation uint64, success bool) {
+	b.mu.Lock()
+	now := b.clock.Now()
+	state, transitions := b.currentState(now, nil)
+	if generation != b.generation {
+		// The outcome belongs to the previous state.
+		b.mu.Unlock()
+		b.notify(transitions)
+		return
+	}
+
+	if success {
+		b.counts.success()
+		if state == StateHalfOpen && b.counts.ConsecutiveSuccesses >= b.settings.HalfOpenRequests {
+			transitions = b.setState(StateClosed, now, transitions)
+		}
+	} else {
+		b.counts.failure()
+		if state == StateHalfOpen || b.settings.Trip(b.counts) {
+			transitions = b.setState(StateOpen, now, transitions)
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(transitions)
+}
+
+// currentState applies the time-based transitions and returns the state.
+func (b *CircuitBreaker[T]) currentState(now time.Time, transitions []breakerTransition) (BreakerState, []breakerTransition) {
+	switch b.state {
+	case StateClosed:
+		if !b.expiry.IsZero() && !b.expiry.After(now) {
+			b.newGeneration(now)
+		}
+	case StateOpen:
+		if !b.expiry.After(now) {
+			transitions = b.setState(StateHalfOpen, now, transitions)
+		}
+	}
+	return b.state, transitions
+}
+
+func (b *CircuitBreaker[T]) setState(state BreakerState, now time.Time, transitions []breakerTransition) []breakerTransition {
+	if b.state == state {
+		return transitions
+	}
+	transitions = append(transitions, breakerTransition{from: b.state, to: state})
+	b.state = state
+	b.newGeneration(now)
+	return transitions
+}
+
+func (b *CircuitBreaker[T]) newGeneration(now time.Time) {
+	b.generation++
+	b.counts = BreakerCounts{}
+
+	switch b.state {
+	case StateClosed:
+		if b.settings.Interval > 0 {
+			b.expiry = now.Add(b.settings.Interval)
+		} else {
+			b.expiry = time.Time{}
+		}
+	case StateOpen:
+		b.expiry = now.Add(b.settings.OpenTimeout)
+	default:
+		b.expiry = time.Time{}
+	}
+}
+
+// notify calls the state change callback outside of the lock, so the callback can use the breaker.
+func (b *CircuitBreaker[T]) notify(transitions []breakerTransition) {
+	if b.settings.OnStateChange == nil {
+		return
+	}
+	for _, t := range transitions {
+		b.settings.OnStateChange(t.from, t.to)
+	}
+}
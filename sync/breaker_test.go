@@ -0,0 +1,173 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errBreakerTest = errors.New("failed")
+
+func breakerCall(b *CircuitBreaker[int], fail bool) error {
+	_, err := b.Execute(context.Background(), func(context.Context) (int, error) {
+		if fail {
+			return 0, errBreakerTest
+		}
+		return 1, nil
+	})
+	return err
+}
+
+func TestCircuitBreakerConsecutiveFailures(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	var transitions []string
+	b := NewCircuitBreaker[int](BreakerSettings{
+		Trip:        ConsecutiveFailures(3),
+		OpenTimeout: time.Minute,
+		OnStateChange: func(from, to BreakerState) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	}, WithClock(clock))
+
+	breakerCall(b, true)
+	breakerCall(b, true)
+	breakerCall(b, false)
+	breakerCall(b, true)
+	breakerCall(b, true)
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("State: got %s, want %s", got, StateClosed)
+	}
+	breakerCall(b, true)
+	if got := b.State(); got != StateOpen {
+		t.Fatalf("State: got %s, want %s", got, StateOpen)
+	}
+
+	called := false
+	_, err := b.Execute(context.Background(), func(context.Context) (int, error) {
+		called = true
+		return 0, nil
+	})
+	if err != ErrBreakerOpen || called {
+		t.Errorf("Execute of the open breaker: got %v, called %t; want %v, not called", err, called, ErrBreakerOpen)
+	}
+
+	clock.Advance(time.Minute)
+	if got := b.State(); got != StateHalfOpen {
+		t.Fatalf("State: got %s, want %s", got, StateHalfOpen)
+	}
+	if err := breakerCall(b, true); err != errBreakerTest {
+		t.Errorf("probe error: got %v, want %v", err, errBreakerTest)
+	}
+	if got := b.State(); got != StateOpen {
+		t.Fatalf("State after failed probe: got %s, want %s", got, StateOpen)
+	}
+
+	clock.Advance(time.Minute)
+	if err := breakerCall(b, false); err != nil {
+		t.Errorf("probe error: got %v, want nil", err)
+	}
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("State after successful probe: got %s, want %s", got, StateClosed)
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions: got %v, want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("transitions[%d]: got %s, want %s", i, transitions[i], want[i])
+		}
+	}
+}
+
+func TestCircuitBreakerFailureRatio(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	b := NewCircuitBreaker[int](BreakerSettings{
+		Trip:     FailureRatio(0.5, 4),
+		Interval: time.Minute,
+	}, WithClock(clock))
+
+	breakerCall(b, true)
+	breakerCall(b, true)
+	breakerCall(b, false)
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("State before min requests: got %s, want %s", got, StateClosed)
+	}
+
+	// The interval clears the counts.
+	clock.Advance(time.Minute)
+	b.State()
+	if got := b.Counts().Requests; got != 0 {
+		t.Errorf("Requests after the interval: got %d, want 0", got)
+	}
+	breakerCall(b, false)
+	breakerCall(b, false)
+	breakerCall(b, false)
+	breakerCall(b, true)
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("State below ratio: got %s, want %s", got, StateClosed)
+	}
+	breakerCall(b, true)
+	breakerCall(b, true)
+	if got := b.State(); got != StateOpen {
+		t.Fatalf("State at ratio: got %s, want %s", got, StateOpen)
+	}
+}
+
+func TestCircuitBreakerHalfOpenLimit(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	b := NewCircuitBreaker[int](BreakerSettings{
+		Trip:             ConsecutiveFailures(1),
+		OpenTimeout:      time.Second,
+		HalfOpenRequests: 2,
+	}, WithClock(clock))
+
+	breakerCall(b, true)
+	clock.Advance(time.Second)
+
+	release := make(chan struct{})
+	done := make(chan error, 2)
+	for range 2 {
+		go func() {
+			_, err := b.Execute(context.Background(), func(context.Context) (int, error) {
+				<-release
+				return 1, nil
+			})
+			done <- err
+		}()
+	}
+	for b.Counts().Requests != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := breakerCall(b, false); err != ErrBreakerOpen {
+		t.Errorf("request over the half-open limit: got %v, want %v", err, ErrBreakerOpen)
+	}
+	close(release)
+	<-done
+	<-done
+	if got := b.State(); got != StateClosed {
+		t.Errorf("State: got %s, want %s", got, StateClosed)
+	}
+}
+
+func TestCircuitBreakerCanceled(t *testing.T) {
+	t.Parallel()
+
+	b := NewCircuitBreaker[int](BreakerSettings{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := b.Execute(ctx, func(context.Context) (int, error) { return 1, nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Execute error: got %v, want %v", err, context.Canceled)
+	}
+	if got := b.Counts().Requests; got != 0 {
+		t.Errorf("Requests: got %d, want 0", got)
+	}
+}
@@ -0,0 +1,214 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"sync"
+)
+
+// ErrBroadcasterClosed is returned by publishing to a closed Broadcaster.
+var ErrBroadcasterClosed = errors.New("broadcaster is closed")
+
+// OverflowPolicy defines what happens when a value is published to a subscriber whose buffer is full.
+type OverflowPolicy int
+
+const (
+	// Block makes the publisher wait until the subscriber has room for the value.
+	Block OverflowPolicy = iota
+	// DropOldest drops the oldest buffered value to make room for the new one.
+	DropOldest
+	// DropNewest drops the new value.
+	DropNewest
+)
+
+// Broadcaster fans published values out to all subscribers. Each subscriber has its own buffer and overflow policy.
+type Broadcaster[T any] struct {
+	latest bool
+	done   chan struct{}
+	close  sync.Once
+
+	publish sync.Mutex // serializes publishers
+	mu      sync.Mutex
+	subs    map[*subscriber[T]]struct{}
+	closed  bool
+	value   T
+	has     bool
+}
+
+type subscriber[T any] struct {
+	ch     chan T
+	policy OverflowPolicy
+	done   chan struct{}
+	once   sync.Once
+
+	mu     sync.Mutex // guards sending to ch against closing it
+	closed bool
+}
+
+// NewBroadcaster creates a new Broadcaster.
+func NewBroadcaster[T any]() *Broadcaster[T] {
+	return &Broadcaster[T]{
+		done: make(chan struct{}),
+		subs: make(map[*subscriber[T]]struct{}),
+	}
+}
+
+// NewLatestBroadcaster creates a new Broadcaster that remembers the last published value
+// and sends it to new subscribers first, so they start with the current state, like a config.
+func NewLatestBroadcaster[T any]() *Broadcaster[T] {
+	b := NewBroadcaster[T]()
+	b.latest = true
+	return b
+}
+
+// Subscribe returns the channel of published values with the buffer of the size and the unsubscribe function.
+// The buffer of subscribers with the DropOldest and DropNewest policies holds at least one value.
+// The channel is closed after unsubscribing or closing the broadcaster.
+func (b *Broadcaster[T]) Subscribe(size int, policy OverflowPolicy) (<-chan T, func()) {
+	if b.latest || policy != Block {
+		size = max(size, 1)
+	}
+	s := &subscriber[T]{ch: make(chan T, size), policy: policy, done: make(chan struct{})}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(s.ch)
+		return s.ch, func() {}
+	}
+	if b.latest && b.has {
+		s.ch <- b.value
+	}
+	b.subs[s] = struct{}{}
+	return s.ch, func() { b.unsubscribe(s) }
+}
+
+// SubscribeSeq is like Subscribe but returns the sequence of published values.
+// The sequence can be iterated once. It unsubscribes when the iteration stops.
+func (b *Broadcaster[T]) SubscribeSeq(size int, policy OverflowPolicy) (iter.Seq[T], func()) {
+	ch, unsubscribe := b.Subscribe(size, policy)
+	return func(yield func(T) bool) {
+		defer unsubscribe()
+		for v := range ch {
+			if !yield(v) {
+				return
+			}
+		}
+	}, unsubscribe
+}
+
+// Publish sends the value to all subscribers according to their overflow policies.
+// If ctx is done while waiting for a blocking subscriber, Publish returns ctx.Err(),
+// and the remaining subscribers do not get the value.
+// Subscribers that subscribe while the value is being published do not get it, unless it is the latest value.
+func (b *Broadcaster[T]) Publish(ctx context.Context, v T) error {
+	b.publish.Lock()
+	defer b.publish.Unlock()
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBroadcasterClosed
+	}
+	if b.latest {
+		b.value, b.has = v, true
+	}
+	subs := make([]*subscriber[T], 0, len(b.subs))
+	for s := range b.subs {
+		subs = append(subs, s)
+	}
+	b.mu.Unlock()
+
+	// Slow subscribers do not block subscribing and unsubscribing.
+	for _, s := range subs {
+		if err := b.send(ctx, s, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close ends all subscriptions. Subsequent publishing fails with ErrBroadcasterClosed.
+func (b *Broadcaster[T]) Close() {
+	b.close.Do(func() { close(b.done) })
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for s := range b.subs {
+		s.close()
+	}
+	b.subs = nil
+}
+
+func (b *Broadcaster[T]) send(ctx context.Context, s *subscriber[T], v T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	switch s.policy {
+	case DropNewest:
+		select {
+		case s.ch <- v:
+		default:
+		}
+	case DropOldest:
+		for {
+			select {
+			case s.ch <- v:
+				return nil
+			default:
+			}
+			select {
+			case <-s.ch:
+			default:
+			}
+		}
+	default:
+		select {
+		case s.ch <- v:
+		case <-s.done:
+			select {
+			case <-b.done:
+				// The subscriber is closed by closing the broadcaster.
+				return ErrBroadcasterClosed
+			default:
+			}
+		case <-b.done:
+			return ErrBroadcasterClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (b *Broadcaster[T]) unsubscribe(s *subscriber[T]) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		s.close()
+	}
+}
+
+// close closes the channel of the subscriber once the publisher, if any, stops sending to it.
+func (s *subscriber[T]) close() {
+	// Wake the publisher blocked on the subscriber, so that the lock can be taken.
+	s.once.Do(func() { close(s.done) })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	close(s.ch)
+}
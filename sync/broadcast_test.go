@@ -0,0 +1,221 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBroadcaster(t *testing.T) {
+	t.Parallel()
+
+	b := NewBroadcaster[int]()
+	ch1, unsubscribe1 := b.Subscribe(3, Block)
+	ch2, unsubscribe2 := b.Subscribe(3, Block)
+	defer unsubscribe2()
+
+	for i := range 3 {
+		if err := b.Publish(context.Background(), i); err != nil {
+			t.Fatalf("Publish returns error %v", err)
+		}
+	}
+	for _, ch := range []<-chan int{ch1, ch2} {
+		for want := range 3 {
+			if got := <-ch; got != want {
+				t.Errorf("received %d, want %d", got, want)
+			}
+		}
+	}
+
+	unsubscribe1()
+	if _, ok := <-ch1; ok {
+		t.Error("channel is not closed after unsubscribing")
+	}
+	b.Publish(context.Background(), 3)
+	if got := <-ch2; got != 3 {
+		t.Errorf("received %d, want 3", got)
+	}
+}
+
+func TestBroadcasterBlock(t *testing.T) {
+	t.Parallel()
+
+	b := NewBroadcaster[int]()
+	ch, unsubscribe := b.Subscribe(1, Block)
+	b.Publish(context.Background(), 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.Publish(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Publish error = %v; want %v", err, context.DeadlineExceeded)
+	}
+
+	// Unsubscribing releases the blocked publisher.
+	done := make(chan error)
+	go func() { done <- b.Publish(context.Background(), 3) }()
+	time.Sleep(10 * time.Millisecond)
+	unsubscribe()
+	if err := <-done; err != nil {
+		t.Errorf("Publish returns error %v", err)
+	}
+	if got := <-ch; got != 1 {
+		t.Errorf("received %d, want 1", got)
+	}
+}
+
+func TestBroadcasterDrop(t *testing.T) {
+	t.Parallel()
+
+	b := NewBroadcaster[int]()
+	oldest, unsubscribeOldest := b.Subscribe(2, DropOldest)
+	newest, unsubscribeNewest := b.Subscribe(2, DropNewest)
+	for i := range 4 {
+		b.Publish(context.Background(), i)
+	}
+	unsubscribeOldest()
+	unsubscribeNewest()
+
+	if got := slices.Collect(chanSeq(oldest)); !slices.Equal(got, []int{2, 3}) {
+		t.Errorf("DropOldest subscriber received %v, want [2 3]", got)
+	}
+	if got := slices.Collect(chanSeq(newest)); !slices.Equal(got, []int{0, 1}) {
+		t.Errorf("DropNewest subscriber received %v, want [0 1]", got)
+	}
+}
+
+func TestBroadcasterDropUnbuffered(t *testing.T) {
+	t.Parallel()
+
+	b := NewBroadcaster[int]()
+	ch, unsubscribe := b.Subscribe(0, DropOldest)
+	for i := range 3 {
+		b.Publish(context.Background(), i)
+	}
+	unsubscribe()
+
+	if got := slices.Collect(chanSeq(ch)); !slices.Equal(got, []int{2}) {
+		t.Errorf("DropOldest subscriber received %v, want [2]", got)
+	}
+}
+
+func TestBroadcasterSubscribeWhilePublishing(t *testing.T) {
+	t.Parallel()
+
+	b := NewBroadcaster[int]()
+	slow, _ := b.Subscribe(0, Block)
+	done := make(chan error)
+	go func() { done <- b.Publish(context.Background(), 1) }()
+	time.Sleep(10 * time.Millisecond)
+
+	// The publisher blocked on the slow subscriber does not block subscribing.
+	subscribed := make(chan struct{})
+	go func() {
+		_, unsubscribe := b.Subscribe(1, Block)
+		unsubscribe()
+		close(subscribed)
+	}()
+	select {
+	case <-subscribed:
+	case <-time.After(time.Second):
+		t.Fatal("Subscribe is blocked by the publisher")
+	}
+
+	if got := <-slow; got != 1 {
+		t.Errorf("received %d, want 1", got)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Publish returns error %v", err)
+	}
+}
+
+func TestLatestBroadcaster(t *testing.T) {
+	t.Parallel()
+
+	b := NewLatestBroadcaster[string]()
+	b.Publish(context.Background(), "v1")
+	b.Publish(context.Background(), "v2")
+
+	seq, _ := b.SubscribeSeq(0, DropOldest)
+	done := make(chan []string)
+	go func() {
+		var got []string
+		for v := range seq {
+			got = append(got, v)
+			if v == "v3" {
+				break
+			}
+		}
+		done <- got
+	}()
+	time.Sleep(10 * time.Millisecond)
+	b.Publish(context.Background(), "v3")
+
+	if got := <-done; !slices.Equal(got, []string{"v2", "v3"}) {
+		t.Errorf("subscriber received %v, want [v2 v3]", got)
+	}
+}
+
+func TestBroadcasterClose(t *testing.T) {
+	t.Parallel()
+
+	b := NewBroadcaster[int]()
+	ch, _ := b.Subscribe(0, Block)
+	done := make(chan error)
+	go func() { done <- b.Publish(context.Background(), 1) }()
+	time.Sleep(10 * time.Millisecond)
+
+	b.Close()
+	if err := <-done; err != ErrBroadcasterClosed {
+		t.Errorf("blocked Publish error = %v; want %v", err, ErrBroadcasterClosed)
+	}
+	if _, ok := <-ch; ok {
+		t.Error("channel is not closed after closing the broadcaster")
+	}
+	if err := b.Publish(context.Background(), 2); err != ErrBroadcasterClosed {
+		t.Errorf("Publish error = %v; want %v", err, ErrBroadcasterClosed)
+	}
+	if ch, _ := b.Subscribe(1, Block); ch != nil {
+		if _, ok := <-ch; ok {
+			t.Error("subscription to the closed broadcaster is not closed")
+		}
+	}
+}
+
+func chanSeq[T any](ch <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range ch {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func TestLatestBroadcasterConcurrent(t *testing.T) {
+	t.Parallel()
+
+	b := NewLatestBroadcaster[int]()
+	b.Publish(context.Background(), 0)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= 100; i++ {
+			b.Publish(context.Background(), i)
+		}
+	}()
+
+	last := 0
+	for range 100 {
+		ch, unsubscribe := b.Subscribe(1, DropOldest)
+		if got := <-ch; got < last {
+			t.Errorf("subscriber received latest value %d after %d", got, last)
+		} else {
+			last = got
+		}
+		unsubscribe()
+	}
+	<-done
+}
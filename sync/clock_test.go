@@ -0,0 +1,71 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Now()
+	clock := NewFakeClock(start)
+	if got := clock.Now(); !got.Equal(start) {
+		t.Errorf("FakeClock.Now() expected %v but got %v", start, got)
+	}
+
+	timer := clock.NewTimer(time.Second)
+	stopped := clock.NewTimer(time.Second)
+	if !stopped.Stop() {
+		t.Errorf("Timer.Stop() expected true for the active timer")
+	}
+	if got := clock.Timers(); got != 1 {
+		t.Errorf("FakeClock.Timers() expected 1 but got %d", got)
+	}
+
+	clock.Advance(time.Second - time.Nanosecond)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired before its deadline")
+	default:
+	}
+
+	clock.Advance(time.Nanosecond)
+	select {
+	case got := <-timer.C():
+		if want := start.Add(time.Second); !got.Equal(want) {
+			t.Errorf("timer expected to fire at %v but got %v", want, got)
+		}
+	default:
+		t.Fatal("timer did not fire at its deadline")
+	}
+	select {
+	case <-stopped.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+
+	if timer.Reset(time.Minute) {
+		t.Errorf("Timer.Reset() expected false for the expired timer")
+	}
+	clock.Set(start.Add(time.Hour))
+	select {
+	case <-timer.C():
+	default:
+		t.Fatal("reset timer did not fire")
+	}
+	if got := clock.Timers(); got != 0 {
+		t.Errorf("FakeClock.Timers() expected 0 but got %d", got)
+	}
+}
+
+func TestRealClock(t *testing.T) {
+	var clock Clock = RealClock{}
+	timer := clock.NewTimer(time.Millisecond)
+	select {
+	case <-timer.C():
+	case <-time.After(time.Second):
+		t.Fatal("timer did not fire")
+	}
+	if clock.Now().IsZero() {
+		t.Errorf("RealClock.Now() returns zero time")
+	}
+}
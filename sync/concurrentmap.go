@@ -0,0 +1,180 @@
+package sync
+
+import (
+	"hash/maphash"
+	"iter"
+	"math/bits"
+	"runtime"
+	"sync"
+
+	"github.com/gotidy/lib/constraints"
+)
+
+// ConcurrentMap is a typed map safe for concurrent use. Keys are spread across shards by the hash function,
+// and each shard has its own lock, so writes to different shards do not contend.
+type ConcurrentMap[K comparable, V any] struct {
+	hash   func(K) uint64
+	mask   uint64
+	shards []mapShard[K, V]
+}
+
+type mapShard[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  map[K]V
+}
+
+// NewConcurrentMap creates a new ConcurrentMap that spreads keys by the hash function across the number of shards
+// rounded up to a power of two. If shards is not positive, it is four times GOMAXPROCS.
+// HashString and HashInteger can be used as hash functions of basic types.
+func NewConcurrentMap[K comparable, V any](hash func(K) uint64, shards int) *ConcurrentMap[K, V] {
+	if shards <= 0 {
+		shards = 4 * runtime.GOMAXPROCS(0)
+	}
+	n := 1 << bits.Len(uint(shards-1))
+	m := &ConcurrentMap[K, V]{
+		hash:   hash,
+		mask:   uint64(n - 1),
+		shards: make([]mapShard[K, V], n),
+	}
+	for i := range m.shards {
+		m.shards[i].m = make(map[K]V)
+	}
+	return m
+}
+
+func (m *ConcurrentMap[K, V]) shard(key K) *mapShard[K, V] {
+	return &m.shards[m.hash(key)&m.mask]
+}
+
+// Load returns the value stored in the map for the key. The ok result indicates whether the value was found.
+func (m *ConcurrentMap[K, V]) Load(key K) (value V, ok bool) {
+	s := m.shard(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok = s.m[key]
+	return value, ok
+}
+
+// Store sets the value for the key.
+func (m *ConcurrentMap[K, V]) Store(key K, value V) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.m[key] = value
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns the given value.
+// The loaded result is true if the value was loaded, false if stored.
+func (m *ConcurrentMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if actual, loaded = s.m[key]; loaded {
+		return actual, true
+	}
+	s.m[key] = value
+	return value, false
+}
+
+// LoadAndDelete deletes the value for the key, returning the previous value if any.
+// The loaded result reports whether the key was present.
+func (m *ConcurrentMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if value, loaded = s.m[key]; loaded {
+		delete(s.m, key)
+	}
+	return value, loaded
+}
+
+// Delete deletes the value for the key.
+func (m *ConcurrentMap[K, V]) Delete(key K) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.m, key)
+}
+
+// Compute atomically replaces the value for the key with the result of the function,
+// which gets the old value and whether it is present. If the function returns keep false, the key is deleted.
+// Compute returns the new value and keep. The function must not use the map.
+func (m *ConcurrentMap[K, V]) Compute(key K, f func(old V, ok bool) (value V, keep bool)) (V, bool) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.m[key]
+	value, keep := f(old, ok)
+	if keep {
+		s.m[key] = value
+	} else if ok {
+		delete(s.m, key)
+	}
+	return value, keep
+}
+
+// Len returns the number of entries in the map. The shards are counted one by one,
+// so the result may not correspond to any moment if the map is modified concurrently.
+func (m *ConcurrentMap[K, V]) Len() int {
+	n := 0
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.RLock()
+		n += len(s.m)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// All returns the sequence of key-value pairs of the map. The sequence is weakly consistent:
+// every shard is read at once, but the shards are read one by one, so concurrent modifications may or may not be seen.
+// The map can be modified during the iteration.
+func (m *ConcurrentMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		type entry struct {
+			k K
+			v V
+		}
+		var entries []entry
+		for i := range m.shards {
+			s := &m.shards[i]
+			s.mu.RLock()
+			entries = entries[:0]
+			for k, v := range s.m {
+				entries = append(entries, entry{k, v})
+			}
+			s.mu.RUnlock()
+
+			for _, e := range entries {
+				if !yield(e.k, e.v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+var hashSeed = maphash.MakeSeed()
+
+// HashString is the hash function of strings for ConcurrentMap.
+func HashString[S ~string](s S) uint64 {
+	return maphash.String(hashSeed, string(s))
+}
+
+// HashInteger is the hash function of integers for ConcurrentMap.
+func HashInteger[T constraints.Integer](v T) uint64 {
+	// SplitMix64 finalizer.
+	x := uint64(v)
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,102 @@
+package sync
+
+import (
+	"maps"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/gotidy/lib/iters"
+)
+
+func TestConcurrentMap(t *testing.T) {
+	t.Parallel()
+
+	m := NewConcurrentMap[string, int](HashString[string], 3)
+	if got := len(m.shards); got != 4 {
+		t.Errorf("number of shards: got %d, want 4", got)
+	}
+
+	m.Store("a", 1)
+	if v, ok := m.Load("a"); !ok || v != 1 {
+		t.Errorf("Load: got %d, %t, want 1, true", v, ok)
+	}
+	if v, loaded := m.LoadOrStore("a", 2); !loaded || v != 1 {
+		t.Errorf("LoadOrStore of present key: got %d, %t, want 1, true", v, loaded)
+	}
+	if v, loaded := m.LoadOrStore("b", 2); loaded || v != 2 {
+		t.Errorf("LoadOrStore of absent key: got %d, %t, want 2, false", v, loaded)
+	}
+	if got := m.Len(); got != 2 {
+		t.Errorf("Len: got %d, want 2", got)
+	}
+
+	if v, loaded := m.LoadAndDelete("a"); !loaded || v != 1 {
+		t.Errorf("LoadAndDelete: got %d, %t, want 1, true", v, loaded)
+	}
+	if _, loaded := m.LoadAndDelete("a"); loaded {
+		t.Error("LoadAndDelete of absent key: got loaded")
+	}
+	m.Delete("b")
+	if _, ok := m.Load("b"); ok {
+		t.Error("Load after Delete: got present key")
+	}
+}
+
+func TestConcurrentMapCompute(t *testing.T) {
+	t.Parallel()
+
+	m := NewConcurrentMap[int, int](HashInteger[int], 0)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				m.Compute(i%10, func(old int, _ bool) (int, bool) { return old + 1, true })
+			}
+		}()
+	}
+	wg.Wait()
+
+	for k, v := range m.All() {
+		if v != 800 {
+			t.Errorf("counter %d: got %d, want 800", k, v)
+		}
+	}
+
+	if _, keep := m.Compute(0, func(int, bool) (int, bool) { return 0, false }); keep {
+		t.Error("Compute: got keep true")
+	}
+	if _, ok := m.Load(0); ok {
+		t.Error("Compute with keep false did not delete the key")
+	}
+}
+
+func TestConcurrentMapAll(t *testing.T) {
+	t.Parallel()
+
+	m := NewConcurrentMap[string, int](HashString[string], 8)
+	want := map[string]int{}
+	for i := range 100 {
+		k := strconv.Itoa(i)
+		m.Store(k, i)
+		want[k] = i
+	}
+	if got := maps.Collect(m.All()); !maps.Equal(got, want) {
+		t.Errorf("All: got %v, want %v", got, want)
+	}
+
+	// The map can be modified during the iteration.
+	for k := range m.All() {
+		m.Delete(k)
+	}
+	if got := m.Len(); got != 0 {
+		t.Errorf("Len after deleting all keys: got %d, want 0", got)
+	}
+
+	m.Store("a", 1)
+	if got := iters.Count(iters.Keys(m.All()), func(string) bool { return true }); got != 1 {
+		t.Errorf("number of keys: got %d, want 1", got)
+	}
+}
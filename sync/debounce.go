@@ -0,0 +1,290 @@
+package sync
+
+import (
+	"sync"
+	"time"
+)
+
+// DebounceSettings configures Debouncer.
+type DebounceSettings struct {
+	// Wait is the quiet period after the last call, after which the function is called.
+	Wait time.Duration
+	// MaxWait is the maximum time the function call can be delayed by a burst of calls. Zero means no limit.
+	MaxWait time.Duration
+	// Leading makes the first call of a burst call the function immediately.
+	// The function is called again at the end of the burst only if there were more calls.
+	Leading bool
+}
+
+// Debouncer coalesces bursts of calls: it calls the function with the last value after a quiet period.
+// The function is never called concurrently with itself. A background goroutine runs until Stop is called.
+type Debouncer[T any] struct {
+	settings DebounceSettings
+	fn       func(T)
+	clock    Clock
+	timer    Timer
+	stop     chan struct{}
+	call     sync.Mutex // serializes calls of fn
+
+	mu         sync.Mutex
+	stopped    bool
+	inBurst    bool
+	burstStart time.Time
+	deadline   time.Time
+	pending    bool
+	value      T
+}
+
+// DebounceOption configures a Debouncer or a Throttler.
+type DebounceOption interface {
+	applyDebounce(clock *Clock)
+}
+
+func (o ClockOption) applyDebounce(c *Clock) { *c = o.clock }
+
+func debounceClock(opts []DebounceOption) Clock {
+	var clock Clock = RealClock{}
+	for _, opt := range opts {
+		opt.applyDebounce(&clock)
+	}
+	return clock
+}
+
+// NewDebouncer creates a new Debouncer of the function. WithClock replaces the real time.
+func NewDebouncer[T any](settings DebounceSettings, fn func(T), opts ...DebounceOption) *Debouncer[T] {
+	d := &Debouncer[T]{
+		settings: settings,
+		fn:       fn,
+		clock:    debounceClock(opts),
+		stop:     make(chan struct{}),
+	}
+	d.timer = d.clock.NewTimer(settings.Wait)
+	d.timer.Stop()
+	go d.loop()
+	return d
+}
+
+// Call schedules calling the function with the value. The value replaces the values of previous calls in the burst.
+func (d *Debouncer[T]) Call(v T) {
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return
+	}
+
+	now := d.clock.Now()
+	leading := false
+	if !d.inBurst {
+		d.inBurst = true
+		d.burstStart = now
+		leading = d.settings.Leading
+	}
+	if !leading {
+		d.pending = true
+		d.value = v
+	}
+	d.deadline = now.Add(d.settings.Wait)
+	if d.settings.MaxWait > 0 {
+		d.deadline = minTime(d.deadline, d.burstStart.Add(d.settings.MaxWait))
+	}
+	d.timer.Reset(d.deadline.Sub(now))
+	d.mu.Unlock()
+
+	if leading {
+		d.invoke(v)
+	}
+}
+
+// Flush immediately calls the function with the pending value, if there is one, and ends the burst.
+func (d *Debouncer[T]) Flush() {
+	d.mu.Lock()
+	d.timer.Stop()
+	v, ok := d.take()
+	d.mu.Unlock()
+
+	if ok {
+		d.invoke(v)
+	}
+}
+
+// Stop drops the pending value and stops the debouncer. Subsequent calls are ignored.
+func (d *Debouncer[T]) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stopped {
+		return
+	}
+	d.stopped = true
+	d.timer.Stop()
+	d.take()
+	close(d.stop)
+}
+
+func (d *Debouncer[T]) loop() {
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-d.timer.C():
+			d.mu.Lock()
+			if !d.inBurst || d.clock.Now().Before(d.deadline) {
+				// The timer was rescheduled after it had fired.
+				d.mu.Unlock()
+				continue
+			}
+			v, ok := d.take()
+			d.mu.Unlock()
+
+			if ok {
+				d.invoke(v)
+			}
+		}
+	}
+}
+
+// take ends the burst and returns the pending value.
+func (d *Debouncer[T]) take() (v T, ok bool) {
+	v, ok = d.value, d.pending
+	var zero T
+	d.value, d.pending, d.inBurst = zero, false, false
+	return v, ok
+}
+
+func (d *Debouncer[T]) invoke(v T) {
+	d.call.Lock()
+	defer d.call.Unlock()
+
+	d.fn(v)
+}
+
+// Throttler calls the function at most once per interval. The first call of an interval calls the function immediately,
+// the last value of the other calls is passed to the function when the interval ends.
+// The function is never called concurrently with itself. A background goroutine runs until Stop is called.
+type Throttler[T any] struct {
+	interval time.Duration
+	fn       func(T)
+	clock    Clock
+	timer    Timer
+	stop     chan struct{}
+	call     sync.Mutex // serializes calls of fn
+
+	mu      sync.Mutex
+	stopped bool
+	next    time.Time
+	pending bool
+	value   T
+}
+
+// NewThrottler creates a new Throttler of the function. WithClock replaces the real time.
+func NewThrottler[T any](interval time.Duration, fn func(T), opts ...DebounceOption) *Throttler[T] {
+	t := &Throttler[T]{
+		interval: interval,
+		fn:       fn,
+		clock:    debounceClock(opts),
+		stop:     make(chan struct{}),
+	}
+	t.timer = t.clock.NewTimer(interval)
+	t.timer.Stop()
+	go t.loop()
+	return t
+}
+
+// Call calls the function with the value now if the interval allows it, otherwise it schedules the call at the end of the interval.
+func (t *Throttler[T]) Call(v T) {
+	t.mu.Lock()
+	if t.stopped {
+		t.mu.Unlock()
+		return
+	}
+
+	now := t.clock.Now()
+	if !t.pending && !now.Before(t.next) {
+		t.next = now.Add(t.interval)
+		t.mu.Unlock()
+		t.invoke(v)
+		return
+	}
+	if !t.pending {
+		t.timer.Reset(t.next.Sub(now))
+	}
+	t.pending = true
+	t.value = v
+	t.mu.Unlock()
+}
+
+// Flush immediately calls the function with the pending value, if there is one.
+func (t *Throttler[T]) Flush() {
+	t.mu.Lock()
+	t.timer.Stop()
+	v, ok := t.take()
+	if ok {
+		t.next = t.clock.Now().Add(t.interval)
+	}
+	t.mu.Unlock()
+
+	if ok {
+		t.invoke(v)
+	}
+}
+
+// Stop drops the pending value and stops the throttler. Subsequent calls are ignored.
+func (t *Throttler[T]) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stopped {
+		return
+	}
+	t.stopped = true
+	t.timer.Stop()
+	t.take()
+	close(t.stop)
+}
+
+func (t *Throttler[T]) loop() {
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-t.timer.C():
+			t.mu.Lock()
+			now := t.clock.Now()
+			if !t.pending {
+				t.mu.Unlock()
+				continue
+			}
+			if now.Before(t.next) {
+				t.timer.Reset(t.next.Sub(now))
+				t.mu.Unlock()
+				continue
+			}
+			v, _ := t.take()
+			t.next = now.Add(t.interval)
+			t.mu.Unlock()
+
+			t.invoke(v)
+		}
+	}
+}
+
+func (t *Throttler[T]) take() (v T, ok bool) {
+	v, ok = t.value, t.pending
+	var zero T
+	t.value, t.pending = zero, false
+	return v, ok
+}
+
+func (t *Throttler[T]) invoke(v T) {
+	t.call.Lock()
+	defer t.call.Unlock()
+
+	t.fn(v)
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
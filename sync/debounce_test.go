@@ -0,0 +1,150 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func expectCalls(t *testing.T, calls <-chan int, want ...int) {
+	t.Helper()
+
+	for _, w := range want {
+		select {
+		case got := <-calls:
+			if got != w {
+				t.Errorf("function was called with %d, want %d", got, w)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("function was not called with %d", w)
+		}
+	}
+	select {
+	case got := <-calls:
+		t.Errorf("unexpected call of the function with %d", got)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestDebouncer(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	calls := make(chan int, 10)
+	d := NewDebouncer(DebounceSettings{Wait: 10 * time.Second}, func(v int) { calls <- v }, WithClock(clock))
+	defer d.Stop()
+
+	d.Call(1)
+	clock.Advance(5 * time.Second)
+	d.Call(2)
+	clock.Advance(5 * time.Second)
+	d.Call(3)
+	clock.Advance(9 * time.Second)
+	expectCalls(t, calls)
+
+	clock.Advance(time.Second)
+	expectCalls(t, calls, 3)
+}
+
+func TestDebouncerLeading(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	calls := make(chan int, 10)
+	d := NewDebouncer(DebounceSettings{Wait: 10 * time.Second, Leading: true}, func(v int) { calls <- v }, WithClock(clock))
+	defer d.Stop()
+
+	d.Call(1)
+	expectCalls(t, calls, 1)
+	clock.Advance(10 * time.Second)
+	expectCalls(t, calls)
+
+	d.Call(2)
+	d.Call(3)
+	d.Call(4)
+	expectCalls(t, calls, 2)
+	clock.Advance(10 * time.Second)
+	expectCalls(t, calls, 4)
+}
+
+func TestDebouncerMaxWait(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	calls := make(chan int, 10)
+	d := NewDebouncer(DebounceSettings{Wait: 10 * time.Second, MaxWait: 25 * time.Second}, func(v int) { calls <- v }, WithClock(clock))
+	defer d.Stop()
+
+	for i := range 5 {
+		d.Call(i)
+		clock.Advance(5 * time.Second)
+	}
+	expectCalls(t, calls, 4)
+}
+
+func TestDebouncerFlushStop(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	calls := make(chan int, 10)
+	d := NewDebouncer(DebounceSettings{Wait: 10 * time.Second}, func(v int) { calls <- v }, WithClock(clock))
+
+	d.Call(1)
+	d.Flush()
+	expectCalls(t, calls, 1)
+	clock.Advance(10 * time.Second)
+	expectCalls(t, calls)
+
+	d.Call(2)
+	d.Stop()
+	d.Call(3)
+	clock.Advance(10 * time.Second)
+	expectCalls(t, calls)
+}
+
+func TestThrottler(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	calls := make(chan int, 10)
+	th := NewThrottler(10*time.Second, func(v int) { calls <- v }, WithClock(clock))
+	defer th.Stop()
+
+	th.Call(1)
+	expectCalls(t, calls, 1)
+	th.Call(2)
+	clock.Advance(5 * time.Second)
+	th.Call(3)
+	expectCalls(t, calls)
+
+	clock.Advance(5 * time.Second)
+	expectCalls(t, calls, 3)
+
+	// The interval started by the trailing call blocks the next one.
+	th.Call(4)
+	expectCalls(t, calls)
+	clock.Advance(10 * time.Second)
+	expectCalls(t, calls, 4)
+
+	clock.Advance(10 * time.Second)
+	th.Call(5)
+	expectCalls(t, calls, 5)
+}
+
+func TestThrottlerFlushStop(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	calls := make(chan int, 10)
+	th := NewThrottler(10*time.Second, func(v int) { calls <- v }, WithClock(clock))
+
+	th.Call(1)
+	th.Call(2)
+	th.Flush()
+	expectCalls(t, calls, 1, 2)
+
+	th.Call(3)
+	th.Stop()
+	th.Call(4)
+	clock.Advance(time.Minute)
+	expectCalls(t, calls)
+}
@@ -0,0 +1,30 @@
+package sync
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is the error that wraps a value of the recovered panic.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+// newPanicError creates a new PanicError with the stack of the current goroutine.
+func newPanicError(v any) *PanicError {
+	return &PanicError{Value: v, Stack: debug.Stack()}
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
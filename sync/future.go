@@ -0,0 +1,258 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrNoFutures is the error of Any and Race called without futures.
+var ErrNoFutures = errors.New("no futures")
+
+// Future is a placeholder for the result of an asynchronous operation.
+type Future[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+// Result is a settled result of a Future.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+func newFuture[T any]() *Future[T] {
+	return &Future[T]{done: make(chan struct{})}
+}
+
+// Async runs the function in a new goroutine and returns the future of its result.
+// A panic in the function is recovered and returned as PanicError.
+func Async[T any](ctx context.Context, fn func(ctx context.Context) (T, error)) *Future[T] {
+	f := newFuture[T]()
+	go func() {
+		f.complete(protect(func() (T, error) { return fn(ctx) }))
+	}()
+	return f
+}
+
+// Resolved returns the future that is already completed with the value.
+func Resolved[T any](v T) *Future[T] {
+	f := newFuture[T]()
+	f.complete(v, nil)
+	return f
+}
+
+// Rejected returns the future that is already completed with the error.
+func Rejected[T any](err error) *Future[T] {
+	f := newFuture[T]()
+	var zero T
+	f.complete(zero, err)
+	return f
+}
+
+// complete sets the result of the future. It must be called once.
+func (f *Future[T]) complete(v T, err error) {
+	f.val, f.err = v, err
+	close(f.done)
+}
+
+// Done returns a channel that is closed when the result is ready.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Await waits until the result is ready and returns it. If ctx is done first, it returns ctx.Err().
+func (f *Future[T]) Await(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-ctx.Done():
+		select {
+		case <-f.done:
+			return f.val, f.err
+		default:
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// Catch returns the future that recovers from the error of f by the function. Successful results are passed through.
+func (f *Future[T]) Catch(fn func(err error) (T, error)) *Future[T] {
+	r := newFuture[T]()
+	go func() {
+		<-f.done
+		if f.err == nil {
+			r.complete(f.val, nil)
+			return
+		}
+		r.complete(protect(func() (T, error) { return fn(f.err) }))
+	}()
+	return r
+}
+
+// Then returns the future of the function applied to the result of f. The error of f is passed through.
+func Then[T, R any](f *Future[T], fn func(v T) (R, error)) *Future[R] {
+	r := newFuture[R]()
+	go func() {
+		<-f.done
+		if f.err != nil {
+			var zero R
+			r.complete(zero, f.err)
+			return
+		}
+		r.complete(protect(func() (R, error) { return fn(f.val) }))
+	}()
+	return r
+}
+
+// All returns the future of all values in the order of futures. It fails with the first error.
+func All[T any](futures ...*Future[T]) *Future[[]T] {
+	r := newFuture[[]T]()
+	go func() {
+		values := make([]T, len(futures))
+		failed := make(chan error, len(futures))
+		var wg sync.WaitGroup
+		for i, f := range futures {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-f.done
+				if f.err != nil {
+					failed <- f.err
+					return
+				}
+				values[i] = f.val
+			}()
+		}
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case err := <-failed:
+			r.complete(nil, err)
+		case <-done:
+			select {
+			case err := <-failed:
+				r.complete(nil, err)
+			default:
+				r.complete(values, nil)
+			}
+		}
+	}()
+	return r
+}
+
+// AllSettled returns the future of the results of all futures in their order. It never fails.
+func AllSettled[T any](futures ...*Future[T]) *Future[[]Result[T]] {
+	r := newFuture[[]Result[T]]()
+	go func() {
+		results := make([]Result[T], len(futures))
+		for i, f := range futures {
+			<-f.done
+			results[i] = Result[T]{Value: f.val, Err: f.err}
+		}
+		r.complete(results, nil)
+	}()
+	return r
+}
+
+// Any returns the future of the first successful value. If all futures fail, it fails with all errors joined.
+func Any[T any](futures ...*Future[T]) *Future[T] {
+	if len(futures) == 0 {
+		return Rejected[T](ErrNoFutures)
+	}
+
+	r := newFuture[T]()
+	go func() {
+		results := make(chan Result[T], len(futures))
+		for _, f := range futures {
+			go func() {
+				<-f.done
+				results <- Result[T]{Value: f.val, Err: f.err}
+			}()
+		}
+
+		errs := make([]error, 0, len(futures))
+		for range futures {
+			res := <-results
+			if res.Err == nil {
+				r.complete(res.Value, nil)
+				return
+			}
+			errs = append(errs, res.Err)
+		}
+		var zero T
+		r.complete(zero, errors.Join(errs...))
+	}()
+	return r
+}
+
+// Race returns the future of the result of the first completed future, successful or not.
+func Race[T any](futures ...*Future[T]) *Future[T] {
+	if len(futures) == 0 {
+		return Rejected[T](ErrNoFutures)
+	}
+
+	r := newFuture[T]()
+	var once sync.Once
+	for _, f := range futures {
+		go func() {
+			<-f.done
+			once.Do(func() { r.complete(f.val, f.err) })
+		}()
+	}
+	return r
+}
+
+// Promise is the writable side of a Future.
+type Promise[T any] struct {
+	future *Future[T]
+	once   sync.Once
+}
+
+// NewPromise creates a new Promise with the pending future.
+func NewPromise[T any]() *Promise[T] {
+	return &Promise[T]{future: newFuture[T]()}
+}
+
+// Future returns the future of the promise.
+func (p *Promise[T]) Future() *Future[T] {
+	return p.future
+}
+
+// Resolve completes the future with the value. It reports whether the future was not completed before.
+func (p *Promise[T]) Resolve(v T) bool {
+	return p.Complete(v, nil)
+}
+
+// Reject completes the future with the error. It reports whether the future was not completed before.
+func (p *Promise[T]) Reject(err error) bool {
+	var zero T
+	return p.Complete(zero, err)
+}
+
+// Complete completes the future with the result. It reports whether the future was not completed before.
+func (p *Promise[T]) Complete(v T, err error) bool {
+	completed := false
+	p.once.Do(func() {
+		p.future.complete(v, err)
+		completed = true
+	})
+	return completed
+}
+
+// protect calls the function and turns its panic into PanicError.
+func protect[T any](fn func() (T, error)) (v T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = newPanicError(r)
+		}
+	}()
+
+	return fn()
+}
@@ -0,0 +1,169 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFutureAwait(t *testing.T) {
+	t.Parallel()
+
+	f := newFuture[int]()
+	go f.complete(1, nil)
+	if v, err := f.Await(context.Background()); v != 1 || err != nil {
+		t.Errorf("Await = %d, %v; want 1, nil", v, err)
+	}
+
+	expected := errors.New("failed")
+	f = newFuture[int]()
+	f.complete(0, expected)
+	if _, err := f.Await(context.Background()); err != expected {
+		t.Errorf("Await error = %v; want %v", err, expected)
+	}
+}
+
+func TestFutureAwaitCanceled(t *testing.T) {
+	t.Parallel()
+
+	f := newFuture[int]()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := f.Await(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Await error = %v; want %v", err, context.DeadlineExceeded)
+	}
+	select {
+	case <-f.Done():
+		t.Error("Done is closed before the result is set")
+	default:
+	}
+}
+
+func TestFutureAsync(t *testing.T) {
+	t.Parallel()
+
+	f := Async(context.Background(), func(context.Context) (int, error) { return 1, nil })
+	if v, err := f.Await(context.Background()); v != 1 || err != nil {
+		t.Errorf("Await = %d, %v; want 1, nil", v, err)
+	}
+
+	f = Async(context.Background(), func(context.Context) (int, error) { panic("boom") })
+	var panicErr *PanicError
+	if _, err := f.Await(context.Background()); !errors.As(err, &panicErr) {
+		t.Errorf("Await error = %v; want PanicError", err)
+	}
+}
+
+func TestFutureThenCatch(t *testing.T) {
+	t.Parallel()
+
+	f := Then(Resolved(2), func(v int) (string, error) { return strconv.Itoa(v * 2), nil })
+	if v, err := f.Await(context.Background()); v != "4" || err != nil {
+		t.Errorf("Then = %q, %v; want 4, nil", v, err)
+	}
+
+	expected := errors.New("failed")
+	called := false
+	f = Then(Rejected[int](expected), func(v int) (string, error) {
+		called = true
+		return "", nil
+	})
+	if _, err := f.Await(context.Background()); err != expected || called {
+		t.Errorf("Then of rejected future = %v, called %t; want %v, not called", err, called, expected)
+	}
+
+	recovered := f.Catch(func(err error) (string, error) { return "recovered", nil })
+	if v, err := recovered.Await(context.Background()); v != "recovered" || err != nil {
+		t.Errorf("Catch = %q, %v; want recovered, nil", v, err)
+	}
+	passed := Resolved("ok").Catch(func(err error) (string, error) { return "recovered", nil })
+	if v, _ := passed.Await(context.Background()); v != "ok" {
+		t.Errorf("Catch of resolved future = %q; want ok", v)
+	}
+}
+
+func TestFutureAll(t *testing.T) {
+	t.Parallel()
+
+	p := NewPromise[int]()
+	f := All(Resolved(1), p.Future(), Resolved(3))
+	go p.Resolve(2)
+	if v, err := f.Await(context.Background()); !slices.Equal(v, []int{1, 2, 3}) || err != nil {
+		t.Errorf("All = %v, %v; want [1 2 3], nil", v, err)
+	}
+
+	expected := errors.New("failed")
+	pending := NewPromise[int]()
+	f = All(Resolved(1), Rejected[int](expected), pending.Future())
+	if _, err := f.Await(context.Background()); err != expected {
+		t.Errorf("All error = %v; want %v", err, expected)
+	}
+
+	if v, err := All[int]().Await(context.Background()); len(v) != 0 || err != nil {
+		t.Errorf("All without futures = %v, %v; want [], nil", v, err)
+	}
+}
+
+func TestFutureAllSettled(t *testing.T) {
+	t.Parallel()
+
+	expected := errors.New("failed")
+	v, err := AllSettled(Resolved(1), Rejected[int](expected)).Await(context.Background())
+	want := []Result[int]{{Value: 1}, {Err: expected}}
+	if !slices.Equal(v, want) || err != nil {
+		t.Errorf("AllSettled = %v, %v; want %v, nil", v, err, want)
+	}
+}
+
+func TestFutureAny(t *testing.T) {
+	t.Parallel()
+
+	err1, err2 := errors.New("first"), errors.New("second")
+	pending := NewPromise[int]()
+	v, err := Any(Rejected[int](err1), pending.Future(), Resolved(3)).Await(context.Background())
+	if v != 3 || err != nil {
+		t.Errorf("Any = %d, %v; want 3, nil", v, err)
+	}
+
+	_, err = Any(Rejected[int](err1), Rejected[int](err2)).Await(context.Background())
+	if !errors.Is(err, err1) || !errors.Is(err, err2) {
+		t.Errorf("Any error = %v; want joined %v and %v", err, err1, err2)
+	}
+
+	if _, err := Any[int]().Await(context.Background()); err != ErrNoFutures {
+		t.Errorf("Any without futures error = %v; want %v", err, ErrNoFutures)
+	}
+}
+
+func TestFutureRace(t *testing.T) {
+	t.Parallel()
+
+	expected := errors.New("failed")
+	pending := NewPromise[int]()
+	_, err := Race(pending.Future(), Rejected[int](expected)).Await(context.Background())
+	if err != expected {
+		t.Errorf("Race error = %v; want %v", err, expected)
+	}
+
+	if _, err := Race[int]().Await(context.Background()); err != ErrNoFutures {
+		t.Errorf("Race without futures error = %v; want %v", err, ErrNoFutures)
+	}
+}
+
+func TestPromise(t *testing.T) {
+	t.Parallel()
+
+	p := NewPromise[int]()
+	if !p.Resolve(1) {
+		t.Error("Resolve of the pending promise returns false")
+	}
+	if p.Reject(errors.New("failed")) {
+		t.Error("Reject of the completed promise returns true")
+	}
+	if v, err := p.Future().Await(context.Background()); v != 1 || err != nil {
+		t.Errorf("Await = %d, %v; want 1, nil", v, err)
+	}
+}
@@ -0,0 +1,204 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/gotidy/lib/collections/slice"
+)
+
+// GroupMode defines how Group handles errors of tasks.
+type GroupMode int
+
+const (
+	// FailFast cancels the group context on the first error, Wait returns that error.
+	FailFast GroupMode = iota
+	// CollectAll runs all tasks to completion, Wait returns all errors joined with errors.Join.
+	CollectAll
+)
+
+// Group runs tasks in goroutines and waits for them, like errgroup.Group.
+// The number of simultaneously running tasks can be limited by a Semaphore.
+// A panic in a task is recovered and reported as PanicError.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	mode   GroupMode
+	sem    *Semaphore
+	wg     sync.WaitGroup
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// NewGroup creates a new Group and the context derived from ctx that is passed to tasks.
+// In FailFast mode the context is canceled by the first error. In both modes it is canceled when Wait returns.
+func NewGroup(ctx context.Context, mode GroupMode) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{ctx: ctx, cancel: cancel, mode: mode}, ctx
+}
+
+// SetLimit limits the number of simultaneously running tasks to n. It must not be called while tasks are running.
+func (g *Group) SetLimit(n int) {
+	g.sem = NewSemaphore(n)
+}
+
+// SetSemaphore limits running tasks by the semaphore, which can be shared with other groups or pools.
+// It must not be called while tasks are running.
+func (g *Group) SetSemaphore(sem *Semaphore) {
+	g.sem = sem
+}
+
+// Go waits until the limit allows running a new task and runs the task in a new goroutine.
+// If the group context is done while waiting, the task is not run, and its error is the context error.
+func (g *Group) Go(f func(ctx context.Context) error) {
+	if g.sem != nil {
+		if err := g.sem.Acquire(g.ctx); err != nil {
+			g.fail(err)
+			return
+		}
+	}
+	g.start(f)
+}
+
+// TryGo runs the task in a new goroutine only if the limit allows it. It reports whether the task was started.
+func (g *Group) TryGo(f func(ctx context.Context) error) bool {
+	if g.sem != nil && !g.sem.TryAcquire() {
+		return false
+	}
+	g.start(f)
+	return true
+}
+
+// Wait blocks until all tasks have completed, then returns their error according to the mode.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(nil)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.mode == FailFast {
+		if len(g.errs) == 0 {
+			return nil
+		}
+		return g.errs[0]
+	}
+	return errors.Join(g.errs...)
+}
+
+func (g *Group) start(f func(ctx context.Context) error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer g.sem.Release()
+		}
+
+		if err := g.run(f); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+func (g *Group) run(f func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = newPanicError(r)
+		}
+	}()
+
+	return f(g.ctx)
+}
+
+func (g *Group) fail(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.mode == FailFast {
+		if len(g.errs) > 0 {
+			return
+		}
+		g.cancel(err)
+	}
+	g.errs = append(g.errs, err)
+}
+
+// ResultGroup is the Group that gathers results of tasks.
+type ResultGroup[T any] struct {
+	*Group
+
+	mu      sync.Mutex
+	results []T
+}
+
+// NewResultGroup creates a new ResultGroup and the context derived from ctx that is passed to tasks.
+func NewResultGroup[T any](ctx context.Context, mode GroupMode) (*ResultGroup[T], context.Context) {
+	g, ctx := NewGroup(ctx, mode)
+	return &ResultGroup[T]{Group: g}, ctx
+}
+
+// Go runs the task like Group.Go. The result of the task takes the place in the order of Go calls.
+func (g *ResultGroup[T]) Go(f func(ctx context.Context) (T, error)) {
+	g.Group.Go(g.task(f))
+}
+
+// TryGo runs the task like Group.TryGo. The result of the task takes the place in the order of successful calls.
+func (g *ResultGroup[T]) TryGo(f func(ctx context.Context) (T, error)) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.sem != nil && !g.sem.TryAcquire() {
+		return false
+	}
+	g.start(g.slot(f))
+	return true
+}
+
+// Wait blocks until all tasks have completed, then returns their results and error according to the mode.
+// Results of failed or not run tasks are zero values.
+func (g *ResultGroup[T]) Wait() ([]T, error) {
+	err := g.Group.Wait()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.results, err
+}
+
+// task reserves the place for the result and returns the task that stores its result there.
+func (g *ResultGroup[T]) task(f func(ctx context.Context) (T, error)) func(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.slot(f)
+}
+
+func (g *ResultGroup[T]) slot(f func(ctx context.Context) (T, error)) func(ctx context.Context) error {
+	i := len(g.results)
+	var zero T
+	g.results = append(g.results, zero)
+	return func(ctx context.Context) error {
+		v, err := f(ctx)
+
+		g.mu.Lock()
+		g.results[i] = v
+		g.mu.Unlock()
+		return err
+	}
+}
+
+// GoBatches splits the slice into batches of the size (see slice.Batch) and processes each batch as a task of the group.
+// It panics if size is not positive.
+func GoBatches[T any](g *Group, s []T, size int, f func(ctx context.Context, batch []T) error) {
+	if size <= 0 {
+		panic("non-positive size for GoBatches")
+	}
+	_ = slice.Batch(s, size, func(batch []T) error {
+		g.Go(func(ctx context.Context) error {
+			return f(ctx, batch)
+		})
+		return nil
+	})
+}
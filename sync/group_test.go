@@ -0,0 +1,170 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroupFailFast(t *testing.T) {
+	t.Parallel()
+
+	g, ctx := NewGroup(context.Background(), FailFast)
+	expected := errors.New("failed")
+	g.Go(func(context.Context) error { return expected })
+	g.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if err := g.Wait(); err != expected {
+		t.Errorf("Wait error = %v; want %v", err, expected)
+	}
+	if cause := context.Cause(ctx); cause != expected {
+		t.Errorf("context cause = %v; want %v", cause, expected)
+	}
+}
+
+func TestGroupCollectAll(t *testing.T) {
+	t.Parallel()
+
+	g, ctx := NewGroup(context.Background(), CollectAll)
+	err1, err2 := errors.New("first"), errors.New("second")
+	var completed atomic.Int32
+	g.Go(func(context.Context) error { return err1 })
+	g.Go(func(context.Context) error { return err2 })
+	g.Go(func(ctx context.Context) error {
+		time.Sleep(10 * time.Millisecond)
+		if ctx.Err() == nil {
+			completed.Add(1)
+		}
+		return nil
+	})
+
+	err := g.Wait()
+	if !errors.Is(err, err1) || !errors.Is(err, err2) {
+		t.Errorf("Wait error = %v; want joined %v and %v", err, err1, err2)
+	}
+	if completed.Load() != 1 {
+		t.Error("task was canceled in CollectAll mode")
+	}
+	if ctx.Err() == nil {
+		t.Error("context is not canceled after Wait")
+	}
+}
+
+func TestGroupLimit(t *testing.T) {
+	t.Parallel()
+
+	const limit = 2
+	g, _ := NewGroup(context.Background(), FailFast)
+	g.SetLimit(limit)
+	var meter concurrencyMeter
+	for range 20 {
+		g.Go(func(context.Context) error {
+			meter.run()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Errorf("Wait returns error %v", err)
+	}
+	if got := meter.max.Load(); got > limit {
+		t.Errorf("max running tasks = %d; want at most %d", got, limit)
+	}
+}
+
+func TestGroupTryGo(t *testing.T) {
+	t.Parallel()
+
+	g, _ := NewGroup(context.Background(), FailFast)
+	g.SetSemaphore(NewSemaphore(1))
+	release := make(chan struct{})
+	if !g.TryGo(func(context.Context) error { <-release; return nil }) {
+		t.Error("TryGo failed while the limit is not reached")
+	}
+	if g.TryGo(func(context.Context) error { return nil }) {
+		t.Error("TryGo succeeded while the limit is reached")
+	}
+	close(release)
+	if err := g.Wait(); err != nil {
+		t.Errorf("Wait returns error %v", err)
+	}
+}
+
+func TestGroupPanic(t *testing.T) {
+	t.Parallel()
+
+	g, _ := NewGroup(context.Background(), FailFast)
+	g.Go(func(context.Context) error { panic("boom") })
+	var panicErr *PanicError
+	if err := g.Wait(); !errors.As(err, &panicErr) {
+		t.Errorf("Wait error = %v; want PanicError", err)
+	}
+}
+
+func TestResultGroup(t *testing.T) {
+	t.Parallel()
+
+	g, _ := NewResultGroup[int](context.Background(), CollectAll)
+	g.SetLimit(3)
+	expected := errors.New("failed")
+	for i := range 10 {
+		g.Go(func(context.Context) (int, error) {
+			time.Sleep(time.Duration(10-i) * time.Millisecond)
+			if i == 5 {
+				return 0, expected
+			}
+			return i * i, nil
+		})
+	}
+
+	results, err := g.Wait()
+	if !errors.Is(err, expected) {
+		t.Errorf("Wait error = %v; want %v", err, expected)
+	}
+	for i, got := range results {
+		want := i * i
+		if i == 5 {
+			want = 0
+		}
+		if got != want {
+			t.Errorf("results[%d] = %d; want %d", i, got, want)
+		}
+	}
+}
+
+func TestGoBatches(t *testing.T) {
+	t.Parallel()
+
+	g, _ := NewGroup(context.Background(), FailFast)
+	g.SetLimit(2)
+	var sum, batches atomic.Int32
+	GoBatches(g, []int32{1, 2, 3, 4, 5}, 2, func(_ context.Context, batch []int32) error {
+		batches.Add(1)
+		for _, v := range batch {
+			sum.Add(v)
+		}
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		t.Errorf("Wait returns error %v", err)
+	}
+	if batches.Load() != 3 || sum.Load() != 15 {
+		t.Errorf("processed %d batches with sum %d; want 3 batches with sum 15", batches.Load(), sum.Load())
+	}
+}
+
+func TestGoBatchesZeroSizePanic(t *testing.T) {
+	t.Parallel()
+
+	g, _ := NewGroup(context.Background(), FailFast)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("GoBatches with zero size did not panic")
+		}
+	}()
+	GoBatches(g, []int{1}, 0, func(context.Context, []int) error { return nil })
+}
@@ -0,0 +1,111 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// KeyedLimiter is a registry of token bucket limiters, one per key, for example per API key or tenant.
+// Limiters are created lazily with the shared config, which can be overridden for individual keys.
+// Limiters idle for longer than the TTL are evicted like expired TTLMap entries.
+type KeyedLimiter[K comparable] struct {
+	every time.Duration
+	burst int
+	clock Clock
+
+	mu        sync.RWMutex
+	overrides map[K]limiterConfig
+	limiters  *TTLMap[K, *Limiter]
+}
+
+// KeyedLimiterOption configures a KeyedLimiter.
+type KeyedLimiterOption interface {
+	applyKeyedLimiter(*keyedLimiterOptions)
+}
+
+type keyedLimiterOptions struct {
+	clock           Clock
+	cleanupInterval time.Duration
+}
+
+func (o ClockOption) applyKeyedLimiter(k *keyedLimiterOptions) { k.clock = o.clock }
+func (o CleanupIntervalOption) applyKeyedLimiter(k *keyedLimiterOptions) {
+	k.cleanupInterval = o.interval
+}
+
+type limiterConfig struct {
+	every time.Duration
+	burst int
+}
+
+// NewKeyedLimiter creates a new KeyedLimiter whose limiters allow events at the rate of one per every duration
+// with bursts of up to burst events. Limiters of keys idle for longer than idleTTL are evicted by the janitor,
+// which runs until Close is called or the context is done. WithClock replaces the real time of the limiters and the janitor,
+// WithCleanupInterval sets how often the janitor runs.
+func NewKeyedLimiter[K comparable](ctx context.Context, every time.Duration, burst int, idleTTL time.Duration, opts ...KeyedLimiterOption) *KeyedLimiter[K] {
+	o := keyedLimiterOptions{clock: RealClock{}}
+	for _, opt := range opts {
+		opt.applyKeyedLimiter(&o)
+	}
+	return &KeyedLimiter[K]{
+		every:     every,
+		burst:     burst,
+		clock:     o.clock,
+		overrides: make(map[K]limiterConfig),
+		limiters:  NewTTLMap[K, *Limiter](ctx, idleTTL, WithClock(o.clock), WithCleanupInterval(o.cleanupInterval)),
+	}
+}
+
+// Limiter returns the limiter of the key, creating it if it is absent. Each call marks the key as used.
+func (l *KeyedLimiter[K]) Limiter(key K) *Limiter {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	lim, _ := l.limiters.getSetTouch(key, func() (*Limiter, error) {
+		every, burst := l.every, l.burst
+		if c, ok := l.overrides[key]; ok {
+			every, burst = c.every, c.burst
+		}
+		return NewLimiter(every, burst, WithClock(l.clock)), nil
+	})
+	return lim
+}
+
+// Allow reports whether an event of the key may happen now.
+func (l *KeyedLimiter[K]) Allow(key K) bool {
+	return l.Limiter(key).Allow()
+}
+
+// Wait blocks until an event of the key may happen. It returns an error if ctx is done first or the event can never happen.
+func (l *KeyedLimiter[K]) Wait(ctx context.Context, key K) error {
+	return l.Limiter(key).Wait(ctx)
+}
+
+// Override sets the config of the key instead of the shared one. The current limiter of the key is replaced.
+func (l *KeyedLimiter[K]) Override(key K, every time.Duration, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.overrides[key] = limiterConfig{every: every, burst: burst}
+	l.limiters.Delete(key)
+}
+
+// RemoveOverride makes the key use the shared config again. The current limiter of the key is replaced.
+func (l *KeyedLimiter[K]) RemoveOverride(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.overrides, key)
+	l.limiters.Delete(key)
+}
+
+// Len returns the number of limiters, including idle ones that are not evicted yet.
+func (l *KeyedLimiter[K]) Len() int {
+	return l.limiters.Len()
+}
+
+// Close stops the janitor.
+func (l *KeyedLimiter[K]) Close() {
+	l.limiters.Close()
+}
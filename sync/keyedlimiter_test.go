@@ -0,0 +1,127 @@
+package sync
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyedLimiterAllow(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	l := NewKeyedLimiter[string](context.Background(), time.Second, 1, time.Minute, WithClock(clock))
+	defer l.Close()
+
+	tries := []bool{l.Allow("a"), l.Allow("a"), l.Allow("b"), l.Allow("b")}
+	clock.Advance(time.Second)
+	tries = append(tries, l.Allow("a"))
+
+	want := []bool{true, false, true, false, true}
+	for i := range tries {
+		if tries[i] != want[i] {
+			t.Errorf("tries[%d]: got %t, want %t", i, tries[i], want[i])
+		}
+	}
+	if got := l.Len(); got != 2 {
+		t.Errorf("Len: got %d, want 2", got)
+	}
+}
+
+func TestKeyedLimiterOverride(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	l := NewKeyedLimiter[string](context.Background(), time.Second, 1, time.Minute, WithClock(clock))
+	defer l.Close()
+
+	l.Allow("a")
+	l.Override("a", time.Second, 3)
+	tries := []bool{l.Allow("a"), l.Allow("a"), l.Allow("a"), l.Allow("a"), l.Allow("b"), l.Allow("b")}
+
+	l.RemoveOverride("a")
+	tries = append(tries, l.Allow("a"), l.Allow("a"))
+
+	want := []bool{true, true, true, false, true, false, true, false}
+	for i := range tries {
+		if tries[i] != want[i] {
+			t.Errorf("tries[%d]: got %t, want %t", i, tries[i], want[i])
+		}
+	}
+}
+
+func TestKeyedLimiterIdle(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	l := NewKeyedLimiter[string](context.Background(), time.Second, 1, time.Minute,
+		WithClock(clock), WithCleanupInterval(time.Second))
+	defer l.Close()
+
+	l.Allow("idle")
+	l.Allow("active")
+	for range 90 {
+		for clock.Timers() == 0 {
+			runtime.Gosched()
+		}
+		clock.Advance(time.Second)
+		l.Allow("active")
+	}
+	for clock.Timers() == 0 {
+		runtime.Gosched()
+	}
+
+	if got := l.Len(); got != 1 {
+		t.Errorf("Len: got %d, want 1", got)
+	}
+	if _, ok := l.limiters.Get("active"); !ok {
+		t.Error("active key was evicted")
+	}
+}
+
+func TestKeyedLimiterSameLimiter(t *testing.T) {
+	t.Parallel()
+
+	l := NewKeyedLimiter[int](context.Background(), time.Second, 1, time.Minute)
+	defer l.Close()
+
+	n := runtime.GOMAXPROCS(0) + 1
+	loops := 1000
+	done := make(chan struct{})
+	go func() {
+		// The janitor runs concurrently with the callers.
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				l.limiters.evictExpired()
+				runtime.Gosched()
+			}
+		}
+	}()
+	defer close(done)
+
+	limiters := make([][]*Limiter, n)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			for key := range loops {
+				limiters[i] = append(limiters[i], l.Limiter(key))
+			}
+		}()
+	}
+	wg.Wait()
+
+	for key := range loops {
+		for i := 1; i < n; i++ {
+			if limiters[i][key] != limiters[0][key] {
+				t.Fatalf("callers got different limiters of key %d", key)
+			}
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package sync
+
+import (
+	"context"
+	"sync"
+)
+
+// rwMaxReaders is the number of tokens of the key semaphore of KeyedRWMutex.
+// A reader acquires one token, a writer acquires all of them.
+const rwMaxReaders = 1 << 30
+
+// KeyedMutex is a set of mutual exclusion locks, one per key, for fine-grained locking, like per entity ID.
+// Locks are reference-counted, so the locks of unused keys are freed. The zero value is an unlocked mutex.
+type KeyedMutex[K comparable] struct {
+	locks keyedSemaphores[K]
+}
+
+// Lock locks the key. If the key is already locked, Lock blocks until it is unlocked.
+func (m *KeyedMutex[K]) Lock(key K) {
+	_ = m.locks.acquire(context.Background(), key, 1, 1)
+}
+
+// LockContext locks the key like Lock, but returns ctx.Err() if ctx is done first.
+func (m *KeyedMutex[K]) LockContext(ctx context.Context, key K) error {
+	return m.locks.acquire(ctx, key, 1, 1)
+}
+
+// TryLock tries to lock the key without blocking and reports whether it succeeded.
+func (m *KeyedMutex[K]) TryLock(key K) bool {
+	return m.locks.tryAcquire(key, 1, 1)
+}
+
+// Unlock unlocks the key. It panics if the key is not locked.
+func (m *KeyedMutex[K]) Unlock(key K) {
+	m.locks.release(key, 1)
+}
+
+// KeyedRWMutex is a set of reader/writer mutual exclusion locks, one per key.
+// Lockers are served in FIFO order, so a waiting writer is not starved by new readers.
+// Locks are reference-counted, so the locks of unused keys are freed. The zero value is an unlocked mutex.
+type KeyedRWMutex[K comparable] struct {
+	locks keyedSemaphores[K]
+}
+
+// Lock locks the key for writing. If the key is already locked for reading or writing, Lock blocks until it is available.
+func (m *KeyedRWMutex[K]) Lock(key K) {
+	_ = m.locks.acquire(context.Background(), key, rwMaxReaders, rwMaxReaders)
+}
+
+// LockContext locks the key for writing like Lock, but returns ctx.Err() if ctx is done first.
+func (m *KeyedRWMutex[K]) LockContext(ctx context.Context, key K) error {
+	return m.locks.acquire(ctx, key, rwMaxReaders, rwMaxReaders)
+}
+
+// TryLock tries to lock the key for writing without blocking and reports whether it succeeded.
+func (m *KeyedRWMutex[K]) TryLock(key K) bool {
+	return m.locks.tryAcquire(key, rwMaxReaders, rwMaxReaders)
+}
+
+// Unlock unlocks the key for writing. It panics if the key is not locked for writing.
+func (m *KeyedRWMutex[K]) Unlock(key K) {
+	m.locks.release(key, rwMaxReaders)
+}
+
+// RLock locks the key for reading. If the key is locked or awaited for writing, RLock blocks until it is available.
+func (m *KeyedRWMutex[K]) RLock(key K) {
+	_ = m.locks.acquire(context.Background(), key, rwMaxReaders, 1)
+}
+
+// RLockContext locks the key for reading like RLock, but returns ctx.Err() if ctx is done first.
+func (m *KeyedRWMutex[K]) RLockContext(ctx context.Context, key K) error {
+	return m.locks.acquire(ctx, key, rwMaxReaders, 1)
+}
+
+// TryRLock tries to lock the key for reading without blocking and reports whether it succeeded.
+func (m *KeyedRWMutex[K]) TryRLock(key K) bool {
+	return m.locks.tryAcquire(key, rwMaxReaders, 1)
+}
+
+// RUnlock unlocks the key for reading. It panics if the key is not locked for reading.
+func (m *KeyedRWMutex[K]) RUnlock(key K) {
+	m.locks.release(key, 1)
+}
+
+// keyedSemaphores is a set of reference-counted semaphores, one per key.
+// Acquiring all tokens of a semaphore locks its key for writing, acquiring fewer of them locks it for reading.
+type keyedSemaphores[K comparable] struct {
+	mu    sync.Mutex
+	locks map[K]*keyedSemaphore
+}
+
+type keyedSemaphore struct {
+	sem     *Semaphore
+	size    int
+	refs    int // holders and waiters
+	writer  bool
+	readers int
+}
+
+func (s *keyedSemaphores[K]) acquire(ctx context.Context, key K, size, n int) error {
+	l := s.ref(key, size)
+	if err := l.sem.AcquireN(ctx, n); err != nil {
+		s.unref(key, l)
+		return err
+	}
+	s.locked(l, n)
+	return nil
+}
+
+func (s *keyedSemaphores[K]) tryAcquire(key K, size, n int) bool {
+	l := s.ref(key, size)
+	if !l.sem.TryAcquireN(n) {
+		s.unref(key, l)
+		return false
+	}
+	s.locked(l, n)
+	return true
+}
+
+// locked records that n tokens of the semaphore have been acquired.
+func (s *keyedSemaphores[K]) locked(l *keyedSemaphore, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n == l.size {
+		l.writer = true
+	} else {
+		l.readers++
+	}
+}
+
+func (s *keyedSemaphores[K]) release(key K, n int) {
+	s.mu.Lock()
+	l := s.locks[key]
+	switch {
+	case l == nil:
+		s.mu.Unlock()
+		panic("unlock of unlocked key")
+	case n == l.size:
+		if !l.writer {
+			s.mu.Unlock()
+			panic("unlock of key not locked for writing")
+		}
+		l.writer = false
+	default:
+		if l.readers == 0 {
+			s.mu.Unlock()
+			panic("runlock of key not locked for reading")
+		}
+		l.readers--
+	}
+	s.mu.Unlock()
+
+	l.sem.ReleaseN(n)
+	s.unref(key, l)
+}
+
+func (s *keyedSemaphores[K]) ref(key K, size int) *keyedSemaphore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.locks == nil {
+		s.locks = make(map[K]*keyedSemaphore)
+	}
+	l := s.locks[key]
+	if l == nil {
+		l = &keyedSemaphore{sem: NewSemaphore(size), size: size}
+		s.locks[key] = l
+	}
+	l.refs++
+	return l
+}
+
+func (s *keyedSemaphores[K]) unref(key K, l *keyedSemaphore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l.refs--
+	if l.refs == 0 {
+		delete(s.locks, key)
+	}
+}
+
+// len returns the number of keys in use.
+func (s *keyedSemaphores[K]) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.locks)
+}
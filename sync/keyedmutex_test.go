@@ -0,0 +1,174 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	t.Parallel()
+
+	var m KeyedMutex[int]
+	const keys, goroutines, loops = 4, 8, 1000
+	counters := make([]int, keys)
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range loops {
+				key := (g + i) % keys
+				m.Lock(key)
+				counters[key]++
+				m.Unlock(key)
+			}
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for _, c := range counters {
+		total += c
+	}
+	if total != goroutines*loops {
+		t.Errorf("total: got %d, want %d", total, goroutines*loops)
+	}
+	if got := m.locks.len(); got != 0 {
+		t.Errorf("keys in use after unlocking: got %d, want 0", got)
+	}
+}
+
+func TestKeyedMutexTryLock(t *testing.T) {
+	t.Parallel()
+
+	var m KeyedMutex[string]
+	tries := []bool{m.TryLock("a"), m.TryLock("a"), m.TryLock("b")}
+	m.Unlock("a")
+	tries = append(tries, m.TryLock("a"))
+
+	want := []bool{true, false, true, true}
+	for i := range tries {
+		if tries[i] != want[i] {
+			t.Errorf("tries[%d]: got %t, want %t", i, tries[i], want[i])
+		}
+	}
+	m.Unlock("a")
+	m.Unlock("b")
+	if got := m.locks.len(); got != 0 {
+		t.Errorf("keys in use after unlocking: got %d, want 0", got)
+	}
+}
+
+func TestKeyedMutexLockContext(t *testing.T) {
+	t.Parallel()
+
+	var m KeyedMutex[string]
+	m.Lock("a")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := m.LockContext(ctx, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("LockContext error = %v; want %v", err, context.DeadlineExceeded)
+	}
+	if err := m.LockContext(ctx, "b"); err == nil {
+		t.Error("LockContext with done context succeeded")
+	}
+	m.Unlock("a")
+	if got := m.locks.len(); got != 0 {
+		t.Errorf("keys in use after unlocking: got %d, want 0", got)
+	}
+}
+
+func TestKeyedMutexUnlockPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("unlock of unlocked key did not panic")
+		}
+	}()
+	var m KeyedMutex[string]
+	m.Unlock("a")
+}
+
+func TestKeyedRWMutex(t *testing.T) {
+	t.Parallel()
+
+	var m KeyedRWMutex[string]
+	if !m.TryRLock("a") || !m.TryRLock("a") {
+		t.Fatal("TryRLock failed while the key is locked for reading")
+	}
+	if m.TryLock("a") {
+		t.Fatal("TryLock succeeded while the key is locked for reading")
+	}
+
+	locked := make(chan struct{})
+	go func() {
+		m.Lock("a")
+		close(locked)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// The waiting writer blocks new readers.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := m.RLockContext(ctx, "a"); err == nil {
+		t.Error("RLockContext succeeded while a writer is waiting")
+	}
+
+	m.RUnlock("a")
+	m.RUnlock("a")
+	<-locked
+	if m.TryRLock("a") {
+		t.Error("TryRLock succeeded while the key is locked for writing")
+	}
+	m.Unlock("a")
+
+	m.RLock("a")
+	m.RUnlock("a")
+	if err := m.LockContext(context.Background(), "a"); err != nil {
+		t.Errorf("LockContext returns error %v", err)
+	}
+	m.Unlock("a")
+	if got := m.locks.len(); got != 0 {
+		t.Errorf("keys in use after unlocking: got %d, want 0", got)
+	}
+}
+
+func TestKeyedRWMutexUnlockPanic(t *testing.T) {
+	t.Parallel()
+
+	var m KeyedRWMutex[string]
+	m.Lock("a")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("runlock of write-locked key did not panic")
+			}
+		}()
+		m.RUnlock("a")
+	}()
+	m.Unlock("a")
+
+	m.RLock("a")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("unlock of read-locked key did not panic")
+			}
+		}()
+		m.Unlock("a")
+	}()
+	m.RUnlock("a")
+
+	// The key is not corrupted by the wrong unlocks.
+	if !m.TryLock("a") {
+		t.Error("TryLock of unlocked key failed")
+	}
+	m.Unlock("a")
+	if got := m.locks.len(); got != 0 {
+		t.Errorf("number of keys: got %d, want 0", got)
+	}
+}
@@ -0,0 +1,226 @@
+package sync
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrBarrierBroken is returned to the parties waiting at a Barrier whose action panicked.
+var ErrBarrierBroken = errors.New("barrier is broken")
+
+// Latch is a countdown latch: it lets goroutines wait until a number of events have happened.
+// Unlike sync.WaitGroup, waiting can be canceled by a context. A latch cannot be reset.
+type Latch struct {
+	mu    sync.Mutex
+	count int
+	done  chan struct{}
+}
+
+// NewLatch creates a new Latch that opens after n CountDown calls.
+func NewLatch(n int) *Latch {
+	l := &Latch{count: n, done: make(chan struct{})}
+	if n <= 0 {
+		close(l.done)
+	}
+	return l
+}
+
+// CountDown decrements the count of the latch and opens it when the count reaches zero.
+// Calls on the open latch have no effect.
+func (l *Latch) CountDown() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.count <= 0 {
+		return
+	}
+	l.count--
+	if l.count == 0 {
+		close(l.done)
+	}
+}
+
+// Count returns the current count of the latch.
+func (l *Latch) Count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.count
+}
+
+// Done returns a channel that is closed when the latch opens.
+func (l *Latch) Done() <-chan struct{} {
+	return l.done
+}
+
+// Wait blocks until the latch opens. If ctx is done first, it returns ctx.Err().
+func (l *Latch) Wait(ctx context.Context) error {
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		select {
+		case <-l.done:
+			return nil
+		default:
+			return ctx.Err()
+		}
+	}
+}
+
+// Barrier is a cyclic barrier: it lets a fixed number of goroutines wait for each other at a common point.
+// The barrier is reused after the waiting parties are released.
+type Barrier struct {
+	parties int
+	action  func()
+
+	mu      sync.Mutex
+	arrived int
+	gen     *barrierGeneration
+}
+
+// barrierGeneration is a single use of the barrier.
+type barrierGeneration struct {
+	trip   chan struct{}
+	broken bool // set before trip is closed
+}
+
+func (g *barrierGeneration) result() error {
+	if g.broken {
+		return ErrBarrierBroken
+	}
+	return nil
+}
+
+// NewBarrier creates a new Barrier for n parties. The optional action runs once all parties arrive,
+// by the last arriving goroutine, before the others are released. It panics if n is not positive.
+func NewBarrier(n int, action func()) *Barrier {
+	if n <= 0 {
+		panic("non-positive number of parties for NewBarrier")
+	}
+	return &Barrier{parties: n, action: action, gen: &barrierGeneration{trip: make(chan struct{})}}
+}
+
+// Await waits until all parties have called Await on the barrier. If ctx is done first, the party leaves
+// the barrier, so the others keep waiting for another party, and Await returns ctx.Err().
+// If the action panics, the panic is propagated to the last party, and the others get ErrBarrierBroken.
+// The barrier stays usable for the next round.
+func (b *Barrier) Await(ctx context.Context) error {
+	b.mu.Lock()
+	gen := b.gen
+	b.arrived++
+	if b.arrived == b.parties {
+		// Parties arriving while the action runs wait for the next round.
+		b.arrived = 0
+		b.gen = &barrierGeneration{trip: make(chan struct{})}
+		b.mu.Unlock()
+		b.trip(gen)
+		return nil
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-gen.trip:
+		return gen.result()
+	case <-ctx.Done():
+		b.mu.Lock()
+		if b.gen != gen {
+			// The barrier was tripped while we were leaving.
+			b.mu.Unlock()
+			<-gen.trip
+			return gen.result()
+		}
+		b.arrived--
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// trip runs the action and releases the parties of the generation.
+func (b *Barrier) trip(gen *barrierGeneration) {
+	ok := false
+	defer func() {
+		gen.broken = !ok
+		close(gen.trip)
+	}()
+	if b.action != nil {
+		b.action()
+	}
+	ok = true
+}
+
+// Waiting returns the number of parties currently waiting at the barrier.
+func (b *Barrier) Waiting() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.arrived
+}
+
+// Cond is a condition variable whose waiting can be canceled by a context, unlike sync.Cond.
+type Cond struct {
+	// L is held while observing or changing the condition.
+	L sync.Locker
+
+	mu      sync.Mutex
+	waiters list.List
+}
+
+// NewCond returns a new Cond with Locker l.
+func NewCond(l sync.Locker) *Cond {
+	return &Cond{L: l}
+}
+
+// Wait atomically unlocks c.L and suspends execution of the calling goroutine until it is woken by Signal or Broadcast,
+// or ctx is done. Wait locks c.L before returning. If ctx is done first, Wait returns ctx.Err().
+// As with sync.Cond, the caller should check the condition in a loop.
+func (c *Cond) Wait(ctx context.Context) error {
+	ready := make(chan struct{})
+	c.mu.Lock()
+	elem := c.waiters.PushBack(ready)
+	c.mu.Unlock()
+
+	c.L.Unlock()
+	defer c.L.Lock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		select {
+		case <-ready:
+			// Woken while we were leaving, so the wakeup is not lost.
+			return nil
+		default:
+		}
+		c.waiters.Remove(elem)
+		return ctx.Err()
+	}
+}
+
+// Signal wakes one goroutine waiting on c, if there is any.
+func (c *Cond) Signal() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem := c.waiters.Front(); elem != nil {
+		c.waiters.Remove(elem)
+		close(elem.Value.(chan struct{}))
+	}
+}
+
+// Broadcast wakes all goroutines waiting on c.
+func (c *Cond) Broadcast() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for elem := c.waiters.Front(); elem != nil; elem = elem.Next() {
+		close(elem.Value.(chan struct{}))
+	}
+	c.waiters.Init()
+}
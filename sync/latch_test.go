@@ -0,0 +1,258 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func HammerLatch(l *Latch, loops int) {
+	for i := 0; i < loops; i++ {
+		time.Sleep(time.Duration(rand.Int63n(1000)) * time.Millisecond / 1000)
+		l.CountDown()
+	}
+}
+
+func TestLatch(t *testing.T) {
+	t.Parallel()
+
+	n := runtime.GOMAXPROCS(0)
+	loops := 1000 / n
+	l := NewLatch(n * loops)
+	for i := 0; i < n; i++ {
+		go HammerLatch(l, loops)
+	}
+	if err := l.Wait(context.Background()); err != nil {
+		t.Errorf("Wait returns error %v", err)
+	}
+	if got := l.Count(); got != 0 {
+		t.Errorf("Count: got %d, want 0", got)
+	}
+
+	// CountDown of the open latch has no effect.
+	l.CountDown()
+	if got := l.Count(); got != 0 {
+		t.Errorf("Count: got %d, want 0", got)
+	}
+}
+
+func TestLatchWaitCanceled(t *testing.T) {
+	t.Parallel()
+
+	l := NewLatch(2)
+	l.CountDown()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait error = %v; want %v", err, context.DeadlineExceeded)
+	}
+	select {
+	case <-l.Done():
+		t.Error("latch opened before the count reached zero")
+	default:
+	}
+
+	if err := NewLatch(0).Wait(context.Background()); err != nil {
+		t.Errorf("Wait of zero latch returns error %v", err)
+	}
+}
+
+func HammerBarrier(b *Barrier, loops int, phase *atomic.Int32, t *testing.T) {
+	for i := 0; i < loops; i++ {
+		time.Sleep(time.Duration(rand.Int63n(1000)) * time.Millisecond / 1000)
+		if err := b.Await(context.Background()); err != nil {
+			t.Errorf("Await returns error %v", err)
+		}
+		if got := phase.Load(); got != int32(i+1) {
+			t.Errorf("phase after Await: got %d, want %d", got, i+1)
+		}
+		// Wait for all parties to check the phase before the next one.
+		b.Await(context.Background())
+	}
+}
+
+func TestBarrier(t *testing.T) {
+	t.Parallel()
+
+	n := runtime.GOMAXPROCS(0) + 1
+	loops := 100
+	var phase atomic.Int32
+	var actions atomic.Int32
+	b := NewBarrier(n, func() {
+		if actions.Add(1)%2 == 1 {
+			phase.Add(1)
+		}
+	})
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			HammerBarrier(b, loops, &phase, t)
+		}()
+	}
+	wg.Wait()
+
+	if got := actions.Load(); got != int32(2*loops) {
+		t.Errorf("number of actions: got %d, want %d", got, 2*loops)
+	}
+}
+
+func TestBarrierZeroPartiesPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("barrier with zero parties did not panic")
+		}
+	}()
+	NewBarrier(0, nil)
+}
+
+func TestBarrierAwaitCanceled(t *testing.T) {
+	t.Parallel()
+
+	b := NewBarrier(2, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.Await(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Await error = %v; want %v", err, context.DeadlineExceeded)
+	}
+	if got := b.Waiting(); got != 0 {
+		t.Errorf("Waiting after leaving: got %d, want 0", got)
+	}
+
+	done := make(chan error)
+	go func() { done <- b.Await(context.Background()) }()
+	if err := b.Await(context.Background()); err != nil {
+		t.Errorf("Await returns error %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Await returns error %v", err)
+	}
+}
+
+func HammerCond(c *Cond, loops int, queue *[]int) {
+	for i := 0; i < loops; i++ {
+		c.L.Lock()
+		for len(*queue) == 0 {
+			c.Wait(context.Background())
+		}
+		*queue = (*queue)[1:]
+		c.L.Unlock()
+	}
+}
+
+func TestCond(t *testing.T) {
+	t.Parallel()
+
+	n := runtime.GOMAXPROCS(0)
+	loops := 1000 / n
+	var mu sync.Mutex
+	c := NewCond(&mu)
+	var queue []int
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			HammerCond(c, loops, &queue)
+		}()
+	}
+	for i := 0; i < n*loops; i++ {
+		mu.Lock()
+		queue = append(queue, i)
+		mu.Unlock()
+		if i%2 == 0 {
+			c.Signal()
+		} else {
+			c.Broadcast()
+		}
+	}
+	wg.Wait()
+
+	if len(queue) != 0 {
+		t.Errorf("queue length: got %d, want 0", len(queue))
+	}
+}
+
+func TestCondWaitCanceled(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	c := NewCond(&mu)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	mu.Lock()
+	if err := c.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait error = %v; want %v", err, context.DeadlineExceeded)
+	}
+	if mu.TryLock() {
+		t.Error("Wait returned without locking L")
+	}
+	mu.Unlock()
+
+	// The canceled waiter does not take the signal of the others.
+	woken := make(chan struct{})
+	go func() {
+		mu.Lock()
+		c.Wait(context.Background())
+		mu.Unlock()
+		close(woken)
+	}()
+	for {
+		c.mu.Lock()
+		n := c.waiters.Len()
+		c.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		runtime.Gosched()
+	}
+	c.Signal()
+	<-woken
+}
+
+func TestBarrierActionPanic(t *testing.T) {
+	t.Parallel()
+
+	var panicked atomic.Bool
+	b := NewBarrier(2, func() {
+		if panicked.CompareAndSwap(false, true) {
+			panic("action failed")
+		}
+	})
+	done := make(chan error)
+	go func() { done <- b.Await(context.Background()) }()
+	for b.Waiting() != 1 {
+		runtime.Gosched()
+	}
+
+	// The last party runs the action and gets its panic, the others get ErrBarrierBroken.
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("panic of the action is not propagated")
+			}
+		}()
+		b.Await(context.Background())
+	}()
+	if err := <-done; !errors.Is(err, ErrBarrierBroken) {
+		t.Errorf("Await error = %v; want %v", err, ErrBarrierBroken)
+	}
+
+	// The barrier is usable after the panic.
+	go func() { done <- b.Await(context.Background()) }()
+	if err := b.Await(context.Background()); err != nil {
+		t.Errorf("Await returns error %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Await returns error %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// Lazy is a value that is loaded on the first use. Unlike sync.OnceValues, only successful loads are cached:
+// failed loads are retried by the next use, optionally not earlier than the backoff allows.
+type Lazy[T any] struct {
+	load  func(ctx context.Context) (T, error)
+	opts  lazyOptions
+	value atomic.Pointer[T]
+
+	mu       Mutex
+	failures int
+	delay    time.Duration
+	err      error
+	retryAt  time.Time
+}
+
+// LazyOption configures a Lazy value.
+type LazyOption interface {
+	applyLazy(*lazyOptions)
+}
+
+type lazyOptions struct {
+	clock   Clock
+	backoff Backoff
+}
+
+type lazyOptionFunc func(*lazyOptions)
+
+func (f lazyOptionFunc) applyLazy(o *lazyOptions) { f(o) }
+
+func (o ClockOption) applyLazy(l *lazyOptions) { l.clock = o.clock }
+
+// WithLoadBackoff sets the backoff between failed loads, during which the last error is returned without loading.
+func WithLoadBackoff(b Backoff) LazyOption {
+	return lazyOptionFunc(func(o *lazyOptions) {
+		o.backoff = b
+	})
+}
+
+// NewLazy creates a new Lazy value loaded by the function.
+// WithLoadBackoff sets the backoff between failed loads, WithClock replaces the real time.
+func NewLazy[T any](load func(ctx context.Context) (T, error), opts ...LazyOption) *Lazy[T] {
+	o := lazyOptions{clock: RealClock{}}
+	for _, opt := range opts {
+		opt.applyLazy(&o)
+	}
+	return &Lazy[T]{load: load, opts: o}
+}
+
+// Get returns the loaded value. If the value is not loaded yet, Get loads it, and concurrent callers wait for the load.
+// If ctx is done while waiting, Get returns ctx.Err(). A load that fails because ctx is done
+// is not counted as a failure, so it does not affect other callers.
+func (l *Lazy[T]) Get(ctx context.Context) (T, error) {
+	if v := l.value.Load(); v != nil {
+		return *v, nil
+	}
+
+	var zero T
+	if err := l.mu.LockContext(ctx); err != nil {
+		return zero, err
+	}
+	defer l.mu.Unlock()
+
+	if v := l.value.Load(); v != nil {
+		return *v, nil
+	}
+	now := l.opts.clock.Now()
+	if l.err != nil && now.Before(l.retryAt) {
+		return zero, l.err
+	}
+
+	v, err := l.load(ctx)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+			return zero, err
+		}
+		l.failures++
+		l.err = err
+		if l.opts.backoff != nil {
+			l.delay = l.opts.backoff(l.failures, l.delay)
+			l.retryAt = now.Add(l.delay)
+		}
+		return zero, err
+	}
+	l.value.Store(&v)
+	l.failures, l.delay, l.err, l.retryAt = 0, 0, nil, time.Time{}
+	return v, nil
+}
+
+// Peek returns the value if it is loaded, without loading it.
+func (l *Lazy[T]) Peek() (T, bool) {
+	if v := l.value.Load(); v != nil {
+		return *v, true
+	}
+	var zero T
+	return zero, false
+}
+
+// Reset drops the loaded value and the failures, so the next Get loads the value again.
+func (l *Lazy[T]) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.value.Store(nil)
+	l.failures, l.delay, l.err, l.retryAt = 0, 0, nil, time.Time{}
+}
+
+// OnceValueRetry returns a function that invokes f only once and returns its values, like sync.OnceValues,
+// but an error is not cached: the next call invokes f again until it succeeds.
+func OnceValueRetry[T any](f func() (T, error)) func() (T, error) {
+	l := NewLazy(func(context.Context) (T, error) { return f() })
+	return func() (T, error) {
+		return l.Get(context.Background())
+	}
+}
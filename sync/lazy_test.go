@@ -0,0 +1,164 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLazy(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	l := NewLazy(func(context.Context) (int, error) {
+		time.Sleep(time.Millisecond)
+		return int(calls.Add(1)), nil
+	})
+	if _, ok := l.Peek(); ok {
+		t.Error("Peek of the not loaded value: got ok")
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := l.Get(context.Background()); v != 1 || err != nil {
+				t.Errorf("Get = %d, %v; want 1, nil", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if v, ok := l.Peek(); !ok || v != 1 {
+		t.Errorf("Peek = %d, %t; want 1, true", v, ok)
+	}
+
+	l.Reset()
+	if _, ok := l.Peek(); ok {
+		t.Error("Peek after Reset: got ok")
+	}
+	if v, _ := l.Get(context.Background()); v != 2 {
+		t.Errorf("Get after Reset = %d; want 2", v)
+	}
+}
+
+func TestLazyRetry(t *testing.T) {
+	t.Parallel()
+
+	expected := errors.New("failed")
+	attempts := 0
+	l := NewLazy(func(context.Context) (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, expected
+		}
+		return attempts, nil
+	})
+	for range 2 {
+		if _, err := l.Get(context.Background()); err != expected {
+			t.Errorf("Get error = %v; want %v", err, expected)
+		}
+	}
+	if v, err := l.Get(context.Background()); v != 3 || err != nil {
+		t.Errorf("Get = %d, %v; want 3, nil", v, err)
+	}
+}
+
+func TestLazyLoadCanceled(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	l := NewLazy(func(ctx context.Context) (int, error) {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	}, WithLoadBackoff(ConstantBackoff(time.Minute)), WithClock(clock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.Get(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Get error = %v; want %v", err, context.Canceled)
+	}
+	// The canceled load of another caller does not start the backoff.
+	if v, err := l.Get(context.Background()); v != 1 || err != nil {
+		t.Errorf("Get = %d, %v; want 1, nil", v, err)
+	}
+}
+
+func TestLazyBackoff(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	expected := errors.New("failed")
+	attempts := 0
+	l := NewLazy(func(context.Context) (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, expected
+		}
+		return attempts, nil
+	}, WithLoadBackoff(ExponentialBackoff(time.Second, 2)), WithClock(clock))
+
+	l.Get(context.Background())
+	l.Get(context.Background())
+	if attempts != 1 {
+		t.Errorf("attempts during the backoff: got %d, want 1", attempts)
+	}
+	clock.Advance(time.Second)
+	l.Get(context.Background())
+	clock.Advance(time.Second)
+	if _, err := l.Get(context.Background()); err != expected || attempts != 2 {
+		t.Errorf("Get during the backoff: got %v after %d attempts, want %v after 2", err, attempts, expected)
+	}
+	clock.Advance(time.Second)
+	if v, err := l.Get(context.Background()); v != 3 || err != nil {
+		t.Errorf("Get = %d, %v; want 3, nil", v, err)
+	}
+}
+
+func TestLazyCanceled(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	l := NewLazy(func(context.Context) (int, error) {
+		<-release
+		return 1, nil
+	})
+	go l.Get(context.Background())
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get error = %v; want %v", err, context.DeadlineExceeded)
+	}
+	close(release)
+	if v, err := l.Get(context.Background()); v != 1 || err != nil {
+		t.Errorf("Get = %d, %v; want 1, nil", v, err)
+	}
+}
+
+func TestOnceValueRetry(t *testing.T) {
+	t.Parallel()
+
+	attempts := 0
+	f := OnceValueRetry(func() (int, error) {
+		attempts++
+		if attempts == 1 {
+			return 0, errors.New("failed")
+		}
+		return attempts, nil
+	})
+	if _, err := f(); err == nil {
+		t.Error("first call: got nil error")
+	}
+	for range 2 {
+		if v, err := f(); v != 2 || err != nil {
+			t.Errorf("call = %d, %v; want 2, nil", v, err)
+		}
+	}
+}
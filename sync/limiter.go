@@ -0,0 +1,257 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrLimitExceeded is returned when a request can never be allowed by a limiter.
+var ErrLimitExceeded = errors.New("rate limit exceeded")
+
+// Limiter is a token bucket rate limiter. The bucket holds up to burst tokens and is refilled with one token every interval.
+// Each event takes one token.
+type Limiter struct {
+	mu     sync.Mutex
+	every  time.Duration
+	burst  int
+	tokens float64
+	last   time.Time
+	event  time.Time // time of the latest allowed or reserved event
+	clock  Clock
+}
+
+// LimiterOption configures a Limiter or a WindowLimiter.
+type LimiterOption interface {
+	applyLimiter(clock *Clock)
+}
+
+func (o ClockOption) applyLimiter(c *Clock) { *c = o.clock }
+
+func limiterClock(opts []LimiterOption) Clock {
+	var clock Clock = RealClock{}
+	for _, opt := range opts {
+		opt.applyLimiter(&clock)
+	}
+	return clock
+}
+
+// NewLimiter creates a new Limiter that allows events at the rate of one per every duration with bursts of up to burst events.
+// Zero every means an infinite rate. The bucket is full initially. WithClock replaces the real time.
+func NewLimiter(every time.Duration, burst int, opts ...LimiterOption) *Limiter {
+	clock := limiterClock(opts)
+	return &Limiter{
+		every:  every,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   clock.Now(),
+		clock:  clock,
+	}
+}
+
+// Allow reports whether an event may happen now. If it may, the event takes a token.
+func (l *Limiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.every <= 0 {
+		return true
+	}
+	now := l.clock.Now()
+	l.advance(now)
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	l.event = now
+	return true
+}
+
+// Reserve takes a token for an event that may happen after Reservation.Delay.
+// If the event can never happen (the burst is zero), the reservation is not OK.
+func (l *Limiter) Reserve() *Reservation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	if l.every <= 0 {
+		return &Reservation{ok: true, at: now}
+	}
+	if l.burst < 1 {
+		return &Reservation{}
+	}
+	l.advance(now)
+	l.tokens--
+
+	at := now
+	if l.tokens < 0 {
+		at = now.Add(time.Duration(-l.tokens * float64(l.every)))
+	}
+	l.event = at
+	return &Reservation{ok: true, limiter: l, at: at}
+}
+
+// Wait blocks until an event may happen. It returns an error if ctx is done first or the event can never happen.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r := l.Reserve()
+	if !r.ok {
+		return ErrLimitExceeded
+	}
+	if err := sleep(ctx, l.clock, r.Delay()); err != nil {
+		// The event does not happen, even if the reserved time has come.
+		r.cancel(true)
+		return err
+	}
+	return nil
+}
+
+// advance refills the bucket with the tokens accumulated since the last update.
+func (l *Limiter) advance(now time.Time) {
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = min(float64(l.burst), l.tokens+float64(elapsed)/float64(l.every))
+		l.last = now
+	}
+}
+
+// Reservation holds a token taken by Limiter.Reserve for an event that may happen later.
+type Reservation struct {
+	ok      bool
+	limiter *Limiter
+	at      time.Time
+}
+
+// OK reports whether the token was taken. If it was not, the event can never happen.
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay returns the duration to wait before the event may happen. Zero means the event may happen immediately.
+func (r *Reservation) Delay() time.Duration {
+	if !r.ok || r.limiter == nil {
+		return 0
+	}
+	return max(0, r.at.Sub(r.limiter.clock.Now()))
+}
+
+// Cancel returns the token to the limiter if the event has not happened yet.
+func (r *Reservation) Cancel() {
+	r.cancel(false)
+}
+
+// cancel returns the token to the limiter. Unless force is set, the token is kept if the event may have happened already.
+// Only the part of the token that later reservations do not rely on is returned: they were timed as if the token was spent.
+func (r *Reservation) cancel(force bool) {
+	if !r.ok || r.limiter == nil {
+		return
+	}
+	l := r.limiter
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	if !force && !r.at.After(now) {
+		return
+	}
+	r.ok = false
+	restore := 1 - float64(l.event.Sub(r.at))/float64(l.every)
+	if restore <= 0 {
+		return
+	}
+	l.advance(now)
+	l.tokens = min(float64(l.burst), l.tokens+restore)
+	if r.at.Equal(l.event) {
+		if prev := r.at.Add(-l.every); !prev.Before(now) {
+			l.event = prev
+		}
+	}
+}
+
+// WindowLimiter is a sliding window counter rate limiter. It allows up to limit events per window.
+// The number of events in the sliding window is estimated by the counts of the current and the previous fixed windows.
+type WindowLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	start  time.Time
+	curr   int
+	prev   int
+	clock  Clock
+}
+
+// NewWindowLimiter creates a new WindowLimiter that allows up to limit events per window. WithClock replaces the real time.
+// It panics if window is not positive.
+func NewWindowLimiter(limit int, window time.Duration, opts ...LimiterOption) *WindowLimiter {
+	if window <= 0 {
+		panic("non-positive window for NewWindowLimiter")
+	}
+	clock := limiterClock(opts)
+	return &WindowLimiter{
+		limit:  limit,
+		window: window,
+		start:  clock.Now(),
+		clock:  clock,
+	}
+}
+
+// Allow reports whether an event may happen now. If it may, the event is counted.
+func (l *WindowLimiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.take(l.clock.Now()) == 0
+}
+
+// Wait blocks until an event may happen. It returns an error if ctx is done first or the event can never happen.
+func (l *WindowLimiter) Wait(ctx context.Context) error {
+	if l.limit < 1 {
+		return ErrLimitExceeded
+	}
+	for {
+		l.mu.Lock()
+		delay := l.take(l.clock.Now())
+		l.mu.Unlock()
+
+		if delay == 0 {
+			return nil
+		}
+		if err := sleep(ctx, l.clock, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// take counts the event if it is allowed and returns zero. Otherwise, it returns the estimated delay before the event may happen.
+func (l *WindowLimiter) take(now time.Time) time.Duration {
+	l.advance(now)
+
+	elapsed := now.Sub(l.start)
+	if l.curr >= l.limit {
+		return l.window - elapsed
+	}
+	weight := 1 - float64(elapsed)/float64(l.window)
+	if float64(l.prev)*weight+float64(l.curr) <= float64(l.limit-1) {
+		l.curr++
+		return 0
+	}
+	// The previous window weight must drop to (limit-1-curr)/prev.
+	at := time.Duration(float64(l.window) * (1 - float64(l.limit-1-l.curr)/float64(l.prev)))
+	return max(at-elapsed, time.Nanosecond)
+}
+
+// advance moves the fixed windows to the current time.
+func (l *WindowLimiter) advance(now time.Time) {
+	n := now.Sub(l.start) / l.window
+	switch {
+	case n < 1:
+		return
+	case n == 1:
+		l.prev, l.curr = l.curr, 0
+	default:
+		l.prev, l.curr = 0, 0
+	}
+	l.start = l.start.Add(n * l.window)
+}
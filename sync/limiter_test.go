@@ -0,0 +1,228 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	l := NewLimiter(time.Second, 2, WithClock(clock))
+	tries := []bool{l.Allow(), l.Allow(), l.Allow()}
+	clock.Advance(time.Second)
+	tries = append(tries, l.Allow(), l.Allow())
+	clock.Advance(time.Hour)
+	tries = append(tries, l.Allow(), l.Allow(), l.Allow())
+
+	want := []bool{true, true, false, true, false, true, true, false}
+	for i := range tries {
+		if tries[i] != want[i] {
+			t.Errorf("tries[%d]: got %t, want %t", i, tries[i], want[i])
+		}
+	}
+}
+
+func TestLimiterReserve(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	l := NewLimiter(time.Second, 1, WithClock(clock))
+	delays := []time.Duration{}
+	for range 3 {
+		r := l.Reserve()
+		if !r.OK() {
+			t.Fatal("Reserve is not OK")
+		}
+		delays = append(delays, r.Delay())
+	}
+
+	want := []time.Duration{0, time.Second, 2 * time.Second}
+	for i := range delays {
+		if delays[i] != want[i] {
+			t.Errorf("delays[%d]: got %v, want %v", i, delays[i], want[i])
+		}
+	}
+
+	r := l.Reserve()
+	r.Cancel()
+	if got := l.Reserve().Delay(); got != 3*time.Second {
+		t.Errorf("delay after Cancel: got %v, want %v", got, 3*time.Second)
+	}
+
+	if NewLimiter(time.Second, 0, WithClock(clock)).Reserve().OK() {
+		t.Error("Reserve is OK with zero burst")
+	}
+}
+
+func TestLimiterCancelMiddleReservation(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	l := NewLimiter(time.Second, 1, WithClock(clock))
+	l.Reserve()
+	r2 := l.Reserve()
+	r3 := l.Reserve()
+
+	// r3 was timed as if the token of r2 was spent, so canceling r2 returns nothing.
+	r2.Cancel()
+	if got := r3.Delay(); got != 2*time.Second {
+		t.Errorf("delay of r3: got %v, want %v", got, 2*time.Second)
+	}
+	if got := l.Reserve().Delay(); got != 3*time.Second {
+		t.Errorf("delay of r4: got %v, want %v", got, 3*time.Second)
+	}
+}
+
+func TestLimiterCancelLastReservation(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	l := NewLimiter(time.Second, 1, WithClock(clock))
+	l.Reserve()
+	l.Reserve()
+	l.Reserve().Cancel()
+	l.Reserve().Cancel()
+	if got := l.Reserve().Delay(); got != 2*time.Second {
+		t.Errorf("delay after canceling the last reservations: got %v, want %v", got, 2*time.Second)
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	l := NewLimiter(time.Second, 1, WithClock(clock))
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait returns error %v", err)
+	}
+
+	done := make(chan error)
+	go func() { done <- l.Wait(context.Background()) }()
+	for clock.Timers() == 0 {
+		runtime.Gosched()
+	}
+	select {
+	case <-done:
+		t.Fatal("Wait returned before the token was refilled")
+	default:
+	}
+	clock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Errorf("Wait returns error %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait error = %v; want %v", err, context.Canceled)
+	}
+	if err := NewLimiter(time.Second, 0).Wait(context.Background()); err != ErrLimitExceeded {
+		t.Errorf("Wait error = %v; want %v", err, ErrLimitExceeded)
+	}
+}
+
+func TestLimiterWaitCanceledKeepsToken(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	l := NewLimiter(time.Second, 1, WithClock(clock))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait error = %v; want %v", err, context.Canceled)
+	}
+	if !l.Allow() {
+		t.Error("canceled Wait took the token")
+	}
+}
+
+func TestLimiterInfinite(t *testing.T) {
+	t.Parallel()
+
+	l := NewLimiter(0, 0)
+	for range 100 {
+		if !l.Allow() {
+			t.Fatal("Allow returns false for the infinite rate")
+		}
+	}
+}
+
+func TestWindowLimiterAllow(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	l := NewWindowLimiter(4, time.Minute, WithClock(clock))
+	tries := []bool{}
+	for range 5 {
+		tries = append(tries, l.Allow())
+	}
+	// A quarter into the next window the previous one weighs 3/4: 4*3/4 = 3 events.
+	clock.Advance(time.Minute + 15*time.Second)
+	tries = append(tries, l.Allow(), l.Allow())
+	// Halfway the previous window weighs 1/2: 4/2 + 1 = 3 events.
+	clock.Advance(15 * time.Second)
+	tries = append(tries, l.Allow(), l.Allow())
+	// Two windows later nothing is counted.
+	clock.Advance(2 * time.Minute)
+	tries = append(tries, l.Allow())
+
+	want := []bool{true, true, true, true, false, true, false, true, false, true}
+	for i := range tries {
+		if tries[i] != want[i] {
+			t.Errorf("tries[%d]: got %t, want %t", i, tries[i], want[i])
+		}
+	}
+}
+
+func TestWindowLimiterWait(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	l := NewWindowLimiter(2, time.Minute, WithClock(clock))
+	for range 2 {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait returns error %v", err)
+		}
+	}
+
+	done := make(chan error)
+	go func() { done <- l.Wait(context.Background()) }()
+	for clock.Timers() == 0 {
+		runtime.Gosched()
+	}
+	select {
+	case <-done:
+		t.Fatal("Wait returned while the window is full")
+	default:
+	}
+
+	// The next window starts with the weight of the previous one 2, and 1 event is allowed at its half.
+	clock.Advance(time.Minute)
+	for clock.Timers() == 0 {
+		runtime.Gosched()
+	}
+	clock.Advance(30 * time.Second)
+	if err := <-done; err != nil {
+		t.Errorf("Wait returns error %v", err)
+	}
+
+	if err := NewWindowLimiter(0, time.Minute).Wait(context.Background()); err != ErrLimitExceeded {
+		t.Errorf("Wait error = %v; want %v", err, ErrLimitExceeded)
+	}
+}
+
+func TestWindowLimiterZeroWindowPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("window limiter with zero window did not panic")
+		}
+	}()
+	NewWindowLimiter(1, 0)
+}
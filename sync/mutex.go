@@ -0,0 +1,134 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Mutex is a mutual exclusion lock whose locking can be canceled by a context.
+// Lockers are served in FIFO order. The zero value is an unlocked mutex.
+type Mutex struct {
+	init sync.Once
+	sem  *Semaphore
+}
+
+func (m *Mutex) semaphore() *Semaphore {
+	m.init.Do(func() { m.sem = NewSemaphore(1) })
+	return m.sem
+}
+
+// Lock locks m. If the lock is already in use, Lock blocks until the mutex is available.
+func (m *Mutex) Lock() {
+	_ = m.semaphore().Acquire(context.Background())
+}
+
+// LockContext locks m like Lock, but returns ctx.Err() if ctx is done first.
+func (m *Mutex) LockContext(ctx context.Context) error {
+	return m.semaphore().Acquire(ctx)
+}
+
+// TryLock tries to lock m without blocking and reports whether it succeeded.
+func (m *Mutex) TryLock() bool {
+	return m.semaphore().TryAcquire()
+}
+
+// Unlock unlocks m. It panics if m is not locked.
+func (m *Mutex) Unlock() {
+	m.semaphore().Release()
+}
+
+// RWMutex is a reader/writer mutual exclusion lock whose locking can be canceled by a context.
+// Lockers are served in FIFO order, so a waiting writer blocks new readers and is not starved by them.
+// The zero value is an unlocked mutex.
+type RWMutex struct {
+	init    sync.Once
+	sem     *Semaphore
+	writer  atomic.Bool
+	readers atomic.Int32
+}
+
+func (m *RWMutex) semaphore() *Semaphore {
+	m.init.Do(func() { m.sem = NewSemaphore(rwMaxReaders) })
+	return m.sem
+}
+
+// Lock locks m for writing. If the lock is already locked for reading or writing, Lock blocks until the lock is available.
+func (m *RWMutex) Lock() {
+	_ = m.LockContext(context.Background())
+}
+
+// LockContext locks m for writing like Lock, but returns ctx.Err() if ctx is done first.
+func (m *RWMutex) LockContext(ctx context.Context) error {
+	if err := m.semaphore().AcquireN(ctx, rwMaxReaders); err != nil {
+		return err
+	}
+	m.writer.Store(true)
+	return nil
+}
+
+// TryLock tries to lock m for writing without blocking and reports whether it succeeded.
+func (m *RWMutex) TryLock() bool {
+	if !m.semaphore().TryAcquireN(rwMaxReaders) {
+		return false
+	}
+	m.writer.Store(true)
+	return true
+}
+
+// Unlock unlocks m for writing. It panics if m is not locked for writing.
+func (m *RWMutex) Unlock() {
+	if !m.writer.CompareAndSwap(true, false) {
+		panic("unlock of mutex not locked for writing")
+	}
+	m.semaphore().ReleaseN(rwMaxReaders)
+}
+
+// RLock locks m for reading. If m is locked for writing or a writer is waiting, RLock blocks until the lock is available.
+func (m *RWMutex) RLock() {
+	_ = m.RLockContext(context.Background())
+}
+
+// RLockContext locks m for reading like RLock, but returns ctx.Err() if ctx is done first.
+func (m *RWMutex) RLockContext(ctx context.Context) error {
+	if err := m.semaphore().Acquire(ctx); err != nil {
+		return err
+	}
+	m.readers.Add(1)
+	return nil
+}
+
+// TryRLock tries to lock m for reading without blocking and reports whether it succeeded.
+func (m *RWMutex) TryRLock() bool {
+	if !m.semaphore().TryAcquire() {
+		return false
+	}
+	m.readers.Add(1)
+	return true
+}
+
+// RUnlock undoes a single RLock call. It panics if m is not locked for reading.
+func (m *RWMutex) RUnlock() {
+	for {
+		n := m.readers.Load()
+		if n == 0 {
+			panic("runlock of mutex not locked for reading")
+		}
+		if m.readers.CompareAndSwap(n, n-1) {
+			break
+		}
+	}
+	m.semaphore().Release()
+}
+
+// RLocker returns a sync.Locker interface that implements the Lock and Unlock methods by calling RLock and RUnlock.
+func (m *RWMutex) RLocker() sync.Locker {
+	return rlocker{m}
+}
+
+type rlocker struct {
+	m *RWMutex
+}
+
+func (r rlocker) Lock()   { r.m.RLock() }
+func (r rlocker) Unlock() { r.m.RUnlock() }
@@ -0,0 +1,192 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func HammerMutex(m *Mutex, loops int, counter *int) {
+	for i := 0; i < loops; i++ {
+		if i%3 == 0 {
+			if m.TryLock() {
+				*counter++
+				m.Unlock()
+			}
+			continue
+		}
+		m.Lock()
+		*counter++
+		m.Unlock()
+	}
+}
+
+func TestMutex(t *testing.T) {
+	t.Parallel()
+
+	n := runtime.GOMAXPROCS(0)
+	loops := 10000 / n
+	var m Mutex
+	var counter int
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			HammerMutex(&m, loops, &counter)
+		}()
+	}
+	wg.Wait()
+
+	if !m.TryLock() {
+		t.Error("TryLock failed on the unlocked mutex")
+	}
+}
+
+func TestMutexLockContext(t *testing.T) {
+	t.Parallel()
+
+	var m Mutex
+	m.Lock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := m.LockContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("LockContext error = %v; want %v", err, context.DeadlineExceeded)
+	}
+	m.Unlock()
+	if err := m.LockContext(context.Background()); err != nil {
+		t.Errorf("LockContext returns error %v", err)
+	}
+}
+
+func TestMutexUnlockPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("unlock of unlocked mutex did not panic")
+		}
+	}()
+	var m Mutex
+	m.Unlock()
+}
+
+func HammerRWMutex(m *RWMutex, loops int, activity *int32, mu *sync.Mutex, t *testing.T) {
+	for i := 0; i < loops; i++ {
+		if i%10 == 0 {
+			m.Lock()
+			mu.Lock()
+			*activity += 10000
+			if *activity != 10000 {
+				t.Errorf("writer runs with activity %d", *activity)
+			}
+			*activity -= 10000
+			mu.Unlock()
+			m.Unlock()
+			continue
+		}
+		m.RLock()
+		mu.Lock()
+		*activity++
+		if *activity >= 10000 {
+			t.Errorf("reader runs with activity %d", *activity)
+		}
+		*activity--
+		mu.Unlock()
+		m.RUnlock()
+	}
+}
+
+func TestRWMutex(t *testing.T) {
+	t.Parallel()
+
+	n := runtime.GOMAXPROCS(0)
+	loops := 10000 / n
+	var m RWMutex
+	var activity int32
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			HammerRWMutex(&m, loops, &activity, &mu, t)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRWMutexWriterPreference(t *testing.T) {
+	t.Parallel()
+
+	var m RWMutex
+	m.RLock()
+	locked := make(chan struct{})
+	go func() {
+		m.Lock()
+		close(locked)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	if m.TryRLock() {
+		t.Error("TryRLock succeeded while a writer is waiting")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := m.RLockContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RLockContext error = %v; want %v", err, context.DeadlineExceeded)
+	}
+
+	m.RUnlock()
+	<-locked
+	if err := m.LockContext(ctx); err == nil {
+		t.Error("LockContext succeeded while the mutex is locked for writing")
+	}
+	m.Unlock()
+
+	l := m.RLocker()
+	l.Lock()
+	if !m.TryRLock() {
+		t.Error("TryRLock failed while the mutex is locked for reading")
+	}
+	m.RUnlock()
+	l.Unlock()
+	if !m.TryLock() {
+		t.Error("TryLock failed on the unlocked mutex")
+	}
+}
+
+func TestRWMutexUnlockPanic(t *testing.T) {
+	t.Parallel()
+
+	var m RWMutex
+	m.Lock()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("runlock of write-locked mutex did not panic")
+			}
+		}()
+		m.RUnlock()
+	}()
+	m.Unlock()
+
+	m.RLock()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("unlock of read-locked mutex did not panic")
+			}
+		}()
+		m.Unlock()
+	}()
+	m.RUnlock()
+
+	// The mutex is not corrupted by the wrong unlocks.
+	if !m.TryLock() {
+		t.Error("TryLock of unlocked mutex failed")
+	}
+}
@@ -0,0 +1,27 @@
+package sync
+
+import "time"
+
+// Each primitive has its own option type, like TTLOption or SemaphoreOption, so options that do not apply
+// to a primitive are rejected by the compiler. The options below are shared: their types implement
+// the option types of all primitives they apply to.
+
+// ClockOption replaces the real time of a time-based primitive.
+type ClockOption struct {
+	clock Clock
+}
+
+// WithClock sets the clock used by time-based primitives instead of the real time.
+func WithClock(c Clock) ClockOption {
+	return ClockOption{clock: c}
+}
+
+// CleanupIntervalOption sets how often expired entries are evicted by a janitor.
+type CleanupIntervalOption struct {
+	interval time.Duration
+}
+
+// WithCleanupInterval sets how often expired entries are evicted. By default, the interval is equal to the TTL.
+func WithCleanupInterval(d time.Duration) CleanupIntervalOption {
+	return CleanupIntervalOption{interval: d}
+}
@@ -1,30 +1,103 @@
 package sync
 
-import "sync"
+import (
+	"sync"
+	"sync/atomic"
+)
 
 // Pool is typed implementation of the sync.Pool.
 // See: https://pkg.go.dev/sync#Pool
 type Pool[T any] struct {
-	pool sync.Pool
+	pool   sync.Pool
+	reset  func(*T)
+	accept func(T) bool
+
+	gets   atomic.Uint64
+	misses atomic.Uint64
+	puts   atomic.Uint64
+	drops  atomic.Uint64
+}
+
+// PoolOption configures the Pool.
+type PoolOption[T any] func(*Pool[T])
+
+// WithReset sets the function that resets an item before it is put in the pool.
+func WithReset[T any](reset func(*T)) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.reset = reset
+	}
 }
 
-// NewPool creates a new Poll.
-func NewPool[T any](new func() T) *Pool[T] {
-	return &Pool[T]{
-		pool: sync.Pool{New: func() any { return new }},
+// WithMaxCap sets the guard that drops items whose capacity exceeds maxCap instead of pooling them,
+// so occasional huge buffers do not stay in memory.
+func WithMaxCap[T any](maxCap int, capacity func(T) int) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.accept = func(x T) bool { return capacity(x) <= maxCap }
 	}
 }
 
+// PoolStats contains counters of the pool operations.
+type PoolStats struct {
+	// Hits is the number of Get calls that returned a pooled item.
+	Hits uint64
+	// Misses is the number of Get calls that created a new item.
+	Misses uint64
+	// Puts is the number of items put in the pool.
+	Puts uint64
+	// Drops is the number of items dropped by the max capacity guard.
+	Drops uint64
+}
+
+// NewPool creates a new Pool. Options WithReset and WithMaxCap configure putting items in the pool.
+func NewPool[T any](new func() T, opts ...PoolOption[T]) *Pool[T] {
+	p := &Pool[T]{}
+	if new != nil {
+		p.pool.New = func() any {
+			p.misses.Add(1)
+			return new()
+		}
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
 // Get selects an arbitrary item from the Pool, removes it from the Pool, and returns it to the caller.
 // Get may choose to ignore the pool and treat it as empty. Callers should not assume any relation
 // between values passed to Put and the values returned by Get.
 //
 // If Get would otherwise return nil and new func is non-nil, Get returns the result of calling new.
+// If new func is nil, Get returns the zero value.
 func (p *Pool[T]) Get() T {
-	return p.pool.Get().(T)
+	p.gets.Add(1)
+	x, ok := p.pool.Get().(T)
+	if !ok && p.pool.New == nil {
+		p.misses.Add(1)
+	}
+	return x
 }
 
-// Put adds x to the pool.
+// Put adds x to the pool. The item is reset by the reset func and dropped if it exceeds the max capacity.
 func (p *Pool[T]) Put(x T) {
+	if p.accept != nil && !p.accept(x) {
+		p.drops.Add(1)
+		return
+	}
+	if p.reset != nil {
+		p.reset(&x)
+	}
+	p.puts.Add(1)
 	p.pool.Put(x)
 }
+
+// Stats returns the counters of the pool operations.
+func (p *Pool[T]) Stats() PoolStats {
+	misses := p.misses.Load()
+	return PoolStats{
+		Hits:   p.gets.Load() - misses,
+		Misses: misses,
+		Puts:   p.puts.Load(),
+		Drops:  p.drops.Load(),
+	}
+}
@@ -0,0 +1,61 @@
+package sync
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPoolGetEmpty(t *testing.T) {
+	p := NewPool(func() *bytes.Buffer { return bytes.NewBuffer(make([]byte, 0, 16)) })
+	b := p.Get()
+	if b == nil {
+		t.Fatal("Pool.Get() expected new item but got nil")
+	}
+	if got := b.Cap(); got != 16 {
+		t.Errorf("Pool.Get() expected item created by new func with cap 16 but got %d", got)
+	}
+	if got := p.Stats().Misses; got != 1 {
+		t.Errorf("PoolStats.Misses expected 1 but got %d", got)
+	}
+}
+
+func TestPoolGetNilNew(t *testing.T) {
+	p := NewPool[*bytes.Buffer](nil)
+	if b := p.Get(); b != nil {
+		t.Errorf("Pool.Get() expected nil but got %v", b)
+	}
+}
+
+func TestPoolReset(t *testing.T) {
+	p := NewPool(
+		func() []byte { return make([]byte, 0, 16) },
+		WithReset(func(b *[]byte) { *b = (*b)[:0] }),
+	)
+	b := append(p.Get(), "data"...)
+	p.Put(b)
+
+	// sync.Pool may drop items at any moment, so only a pooled item is checked.
+	if got := p.Get(); p.Stats().Hits == 1 && len(got) != 0 {
+		t.Errorf("Pool.Get() expected reset item but got %q", got)
+	}
+	if got := p.Stats().Puts; got != 1 {
+		t.Errorf("PoolStats.Puts expected 1 but got %d", got)
+	}
+}
+
+func TestPoolMaxCap(t *testing.T) {
+	p := NewPool(
+		func() []byte { return make([]byte, 0, 16) },
+		WithMaxCap(64, func(b []byte) int { return cap(b) }),
+	)
+	p.Put(make([]byte, 0, 32))
+	p.Put(make([]byte, 0, 128))
+
+	stats := p.Stats()
+	if stats.Puts != 1 {
+		t.Errorf("PoolStats.Puts expected 1 but got %d", stats.Puts)
+	}
+	if stats.Drops != 1 {
+		t.Errorf("PoolStats.Drops expected 1 but got %d", stats.Drops)
+	}
+}
@@ -0,0 +1,257 @@
+package sync
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"iter"
+	"sync"
+)
+
+// ErrQueueClosed is returned by putting to a closed Queue or taking from a closed and drained one.
+var ErrQueueClosed = errors.New("queue is closed")
+
+// Queue is a blocking queue for handing items off between goroutines.
+// A bounded queue blocks producers while it is full, providing backpressure.
+// Closing the queue rejects new items, but the buffered ones can still be taken.
+type Queue[T any] struct {
+	mu       sync.Mutex
+	notEmpty *Cond
+	notFull  *Cond
+	buf      queueBuffer[T]
+	capacity int
+	closed   bool
+}
+
+type queueBuffer[T any] interface {
+	push(v T)
+	pop() T
+	len() int
+}
+
+// NewQueue creates a new FIFO queue holding up to capacity items. A capacity <= 0 means the queue is unbounded.
+func NewQueue[T any](capacity int) *Queue[T] {
+	return newQueue[T](capacity, &fifoBuffer[T]{})
+}
+
+// NewPriorityQueue creates a new queue holding up to capacity items, which are taken in the order defined by less.
+// Items of equal priority are taken in FIFO order. A capacity <= 0 means the queue is unbounded.
+func NewPriorityQueue[T any](capacity int, less func(a, b T) bool) *Queue[T] {
+	return newQueue[T](capacity, &priorityBuffer[T]{less: less})
+}
+
+func newQueue[T any](capacity int, buf queueBuffer[T]) *Queue[T] {
+	q := &Queue[T]{buf: buf, capacity: capacity}
+	q.notEmpty = NewCond(&q.mu)
+	q.notFull = NewCond(&q.mu)
+	return q
+}
+
+// Put adds v to the queue, waiting while the queue is full.
+// Returns ErrQueueClosed if the queue is closed, or ctx.Err() if ctx is done first.
+func (q *Queue[T]) Put(ctx context.Context, v T) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed && q.full() {
+		if err := q.notFull.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if q.closed {
+		return ErrQueueClosed
+	}
+	q.push(v)
+	return nil
+}
+
+// TryPut adds v to the queue without blocking. Returns false if the queue is full or closed.
+func (q *Queue[T]) TryPut(v T) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed || q.full() {
+		return false
+	}
+	q.push(v)
+	return true
+}
+
+// Take removes and returns the head of the queue, waiting while the queue is empty.
+// Returns ErrQueueClosed if the queue is closed and drained, or ctx.Err() if ctx is done first.
+func (q *Queue[T]) Take(ctx context.Context) (v T, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed && q.buf.len() == 0 {
+		if err := q.notEmpty.Wait(ctx); err != nil {
+			return v, err
+		}
+	}
+	if q.buf.len() == 0 {
+		return v, ErrQueueClosed
+	}
+	return q.pop(), nil
+}
+
+// TryTake removes and returns the head of the queue without blocking. Returns false if the queue is empty.
+func (q *Queue[T]) TryTake() (v T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.buf.len() == 0 {
+		return v, false
+	}
+	return q.pop(), true
+}
+
+// DrainTo removes all available items from the queue without blocking, appends them to dst and returns the extended slice.
+func (q *Queue[T]) DrainTo(dst []T) []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := q.buf.len()
+	for range n {
+		dst = append(dst, q.buf.pop())
+	}
+	if n > 0 {
+		q.notFull.Broadcast()
+	}
+	return dst
+}
+
+// All returns an iterator that takes items from the queue until it is closed and drained.
+func (q *Queue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			v, err := q.Take(context.Background())
+			if err != nil || !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of buffered items.
+func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.buf.len()
+}
+
+// Cap returns the capacity of the queue. Zero means the queue is unbounded.
+func (q *Queue[T]) Cap() int {
+	return max(q.capacity, 0)
+}
+
+// Close closes the queue. Waiting producers get ErrQueueClosed, while consumers can take the remaining items.
+// Closing an already closed queue has no effect.
+func (q *Queue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+}
+
+func (q *Queue[T]) full() bool {
+	return q.capacity > 0 && q.buf.len() >= q.capacity
+}
+
+func (q *Queue[T]) push(v T) {
+	q.buf.push(v)
+	q.notEmpty.Signal()
+}
+
+func (q *Queue[T]) pop() T {
+	v := q.buf.pop()
+	q.notFull.Signal()
+	return v
+}
+
+// fifoBuffer is a growable ring buffer.
+type fifoBuffer[T any] struct {
+	items []T
+	head  int
+	n     int
+}
+
+func (b *fifoBuffer[T]) push(v T) {
+	if b.n == len(b.items) {
+		items := make([]T, max(2*len(b.items), 8))
+		copy(items, b.items[b.head:])
+		copy(items[len(b.items)-b.head:], b.items[:b.head])
+		b.items, b.head = items, 0
+	}
+	b.items[(b.head+b.n)%len(b.items)] = v
+	b.n++
+}
+
+func (b *fifoBuffer[T]) pop() T {
+	var zero T
+	v := b.items[b.head]
+	b.items[b.head] = zero
+	b.head = (b.head + 1) % len(b.items)
+	b.n--
+	return v
+}
+
+func (b *fifoBuffer[T]) len() int {
+	return b.n
+}
+
+// priorityBuffer is a binary heap ordered by less, and by insertion order for equal items.
+type priorityBuffer[T any] struct {
+	items []priorityItem[T]
+	less  func(a, b T) bool
+	seq   uint64
+}
+
+type priorityItem[T any] struct {
+	v   T
+	seq uint64
+}
+
+func (b *priorityBuffer[T]) push(v T) {
+	b.seq++
+	heap.Push(b, priorityItem[T]{v: v, seq: b.seq})
+}
+
+func (b *priorityBuffer[T]) pop() T {
+	return heap.Pop(b).(priorityItem[T]).v
+}
+
+func (b *priorityBuffer[T]) len() int {
+	return len(b.items)
+}
+
+// Len, Less, Swap, Push and Pop implement heap.Interface.
+
+func (b *priorityBuffer[T]) Len() int { return len(b.items) }
+
+func (b *priorityBuffer[T]) Less(i, j int) bool {
+	if b.less(b.items[i].v, b.items[j].v) {
+		return true
+	}
+	if b.less(b.items[j].v, b.items[i].v) {
+		return false
+	}
+	return b.items[i].seq < b.items[j].seq
+}
+
+func (b *priorityBuffer[T]) Swap(i, j int) { b.items[i], b.items[j] = b.items[j], b.items[i] }
+
+func (b *priorityBuffer[T]) Push(x any) { b.items = append(b.items, x.(priorityItem[T])) }
+
+func (b *priorityBuffer[T]) Pop() any {
+	n := len(b.items) - 1
+	item := b.items[n]
+	b.items[n] = priorityItem[T]{}
+	b.items = b.items[:n]
+	return item
+}
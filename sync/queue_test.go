@@ -0,0 +1,174 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueue(t *testing.T) {
+	t.Parallel()
+
+	q := NewQueue[int](2)
+	if !q.TryPut(1) || !q.TryPut(2) {
+		t.Fatal("TryPut to a non-full queue failed")
+	}
+	if q.TryPut(3) {
+		t.Error("TryPut to a full queue succeeded")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.Put(ctx, 3); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Put error = %v; want %v", err, context.DeadlineExceeded)
+	}
+
+	if v, ok := q.TryTake(); !ok || v != 1 {
+		t.Errorf("TryTake = %d, %t; want 1, true", v, ok)
+	}
+	if err := q.Put(context.Background(), 3); err != nil {
+		t.Errorf("Put returns error %v", err)
+	}
+	if got := q.DrainTo(nil); !slices.Equal(got, []int{2, 3}) {
+		t.Errorf("DrainTo: got %v, want %v", got, []int{2, 3})
+	}
+	if _, ok := q.TryTake(); ok {
+		t.Error("TryTake from an empty queue succeeded")
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.Take(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Take error = %v; want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestQueueClose(t *testing.T) {
+	t.Parallel()
+
+	q := NewQueue[int](2)
+	q.Put(context.Background(), 1)
+	q.Put(context.Background(), 2)
+
+	blocked := make(chan error)
+	go func() { blocked <- q.Put(context.Background(), 3) }()
+	for {
+		q.mu.Lock()
+		n := q.notFull.waiters.Len()
+		q.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		runtime.Gosched()
+	}
+	q.Close()
+	q.Close()
+
+	if err := <-blocked; !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Put error = %v; want %v", err, ErrQueueClosed)
+	}
+	if q.TryPut(3) {
+		t.Error("TryPut to a closed queue succeeded")
+	}
+	if got := slices.Collect(q.All()); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("remaining items: got %v, want %v", got, []int{1, 2})
+	}
+	if _, err := q.Take(context.Background()); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Take error = %v; want %v", err, ErrQueueClosed)
+	}
+}
+
+func TestQueueUnbounded(t *testing.T) {
+	t.Parallel()
+
+	q := NewQueue[int](0)
+	for i := range 100 {
+		if !q.TryPut(i) {
+			t.Fatalf("TryPut %d to an unbounded queue failed", i)
+		}
+		if i%3 == 0 {
+			q.TryTake()
+		}
+	}
+	q.Close()
+
+	// Every third put was followed by taking the head, so the first 34 items are gone.
+	var want []int
+	for i := 34; i < 100; i++ {
+		want = append(want, i)
+	}
+	if got := slices.Collect(q.All()); !slices.Equal(got, want) {
+		t.Errorf("items: got %v, want %v", got, want)
+	}
+}
+
+func HammerQueue(q *Queue[int], from, loops int) {
+	for i := from; i < from+loops; i++ {
+		if err := q.Put(context.Background(), i); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestQueueHammer(t *testing.T) {
+	t.Parallel()
+
+	n := runtime.GOMAXPROCS(0)
+	loops := 1000
+	q := NewQueue[int](4)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			HammerQueue(q, i*loops, loops)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		q.Close()
+	}()
+
+	var got []int
+	last := make([]int, n)
+	for i := range last {
+		last[i] = -1
+	}
+	for v := range q.All() {
+		got = append(got, v)
+		// Items of a single producer keep their order.
+		if p := v / loops; v <= last[p] {
+			t.Errorf("item %d taken after %d", v, last[p])
+		} else {
+			last[p] = v
+		}
+	}
+	if len(got) != n*loops {
+		t.Errorf("number of items: got %d, want %d", len(got), n*loops)
+	}
+}
+
+func TestPriorityQueue(t *testing.T) {
+	t.Parallel()
+
+	type task struct {
+		prio int
+		name string
+	}
+	q := NewPriorityQueue(0, func(a, b task) bool { return a.prio > b.prio })
+	for _, v := range []task{{1, "a"}, {3, "b"}, {2, "c"}, {3, "d"}, {1, "e"}, {3, "f"}} {
+		q.Put(context.Background(), v)
+	}
+
+	var got []string
+	for _, v := range q.DrainTo(nil) {
+		got = append(got, v.name)
+	}
+	if want := []string{"b", "d", "f", "c", "a", "e"}; !slices.Equal(got, want) {
+		t.Errorf("order: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,146 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff returns the delay before the next attempt.
+// The attempt is the number of failed attempts so far, prev is the previous delay (zero before the first retry).
+type Backoff func(attempt int, prev time.Duration) time.Duration
+
+// ConstantBackoff returns the Backoff with the same delay before every attempt.
+func ConstantBackoff(d time.Duration) Backoff {
+	return func(int, time.Duration) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff returns the Backoff whose delay starts with base and is multiplied by factor after every attempt.
+func ExponentialBackoff(base time.Duration, factor float64) Backoff {
+	return func(attempt int, _ time.Duration) time.Duration {
+		d := float64(base) * math.Pow(factor, float64(attempt-1))
+		if d >= math.MaxInt64 {
+			return math.MaxInt64
+		}
+		return time.Duration(d)
+	}
+}
+
+// DecorrelatedJitterBackoff returns the Backoff whose delay is random between base and three times the previous delay.
+// See: https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+func DecorrelatedJitterBackoff(base time.Duration) Backoff {
+	return func(_ int, prev time.Duration) time.Duration {
+		upper := max(base, 3*prev)
+		if upper <= base {
+			return base
+		}
+		return base + rand.N(upper-base)
+	}
+}
+
+// WithMax caps the delays of the Backoff by d.
+func (b Backoff) WithMax(d time.Duration) Backoff {
+	return func(attempt int, prev time.Duration) time.Duration {
+		return min(b(attempt, prev), d)
+	}
+}
+
+// WithJitter randomizes the delays of the Backoff by up to the fraction of the delay in both directions.
+func (b Backoff) WithJitter(fraction float64) Backoff {
+	return func(attempt int, prev time.Duration) time.Duration {
+		d := float64(b(attempt, prev))
+		return time.Duration(d + d*fraction*(2*rand.Float64()-1))
+	}
+}
+
+// RetryPolicy defines how an operation is retried. Zero fields get the default values.
+type RetryPolicy struct {
+	// Backoff computes delays between attempts. By default, it is ExponentialBackoff(100ms, 2) capped at 30s.
+	Backoff Backoff
+	// MaxAttempts is the maximum number of attempts, including the first one. By default, it is 5.
+	// A negative value means no limit, so the operation is retried until MaxElapsed or the context stops it.
+	MaxAttempts int
+	// MaxElapsed is the maximum time since the first attempt, after which no retry starts. Zero means no limit.
+	MaxElapsed time.Duration
+	// Retryable reports whether the error is worth retrying. By default, all errors are retried.
+	Retryable func(err error) bool
+}
+
+// RetryOption configures retrying.
+type RetryOption interface {
+	applyRetry(clock *Clock)
+}
+
+func (o ClockOption) applyRetry(c *Clock) { *c = o.clock }
+
+// Retry calls fn until it succeeds or the policy stops retrying, and returns the last error.
+// Delays between attempts are interrupted by the context. WithClock replaces the real time.
+func Retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error, opts ...RetryOption) error {
+	_, err := RetryValue(ctx, policy, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	}, opts...)
+	return err
+}
+
+// RetryValue calls fn until it succeeds or the policy stops retrying, and returns the result of the last attempt.
+// WithClock replaces the real time.
+func RetryValue[T any](ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) (T, error), opts ...RetryOption) (T, error) {
+	var clock Clock = RealClock{}
+	for _, opt := range opts {
+		opt.applyRetry(&clock)
+	}
+	backoff := policy.Backoff
+	if backoff == nil {
+		backoff = ExponentialBackoff(100*time.Millisecond, 2).WithMax(30 * time.Second)
+	}
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = 5
+	}
+
+	start := clock.Now()
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		v, err := fn(ctx)
+		switch {
+		case err == nil:
+			return v, nil
+		case policy.Retryable != nil && !policy.Retryable(err):
+			return v, err
+		case maxAttempts > 0 && attempt >= maxAttempts:
+			return v, err
+		}
+
+		delay = backoff(attempt, delay)
+		if policy.MaxElapsed > 0 && clock.Now().Add(delay).Sub(start) > policy.MaxElapsed {
+			return v, err
+		}
+		if ctxErr := sleep(ctx, clock, delay); ctxErr != nil {
+			return v, fmt.Errorf("%w (last error: %w)", ctxErr, err)
+		}
+	}
+}
+
+// RetryLoader wraps the loader, like the getter of TTLPointer.GetSet, to be retried according to the policy.
+// WithClock replaces the real time.
+func RetryLoader[T any](ctx context.Context, policy RetryPolicy, loader func() (*T, error), opts ...RetryOption) func() (*T, error) {
+	return func() (*T, error) {
+		return RetryValue(ctx, policy, func(context.Context) (*T, error) {
+			return loader()
+		}, opts...)
+	}
+}
+
+// RetryFunc wraps the function, like the callback of slice.Batch, to be retried according to the policy.
+// WithClock replaces the real time.
+func RetryFunc[T any](ctx context.Context, policy RetryPolicy, f func(T) error, opts ...RetryOption) func(T) error {
+	return func(t T) error {
+		return Retry(ctx, policy, func(context.Context) error {
+			return f(t)
+		}, opts...)
+	}
+}
@@ -0,0 +1,215 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/gotidy/lib/collections/slice"
+	"github.com/gotidy/lib/ptr"
+)
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	exp := ExponentialBackoff(time.Second, 2).WithMax(5 * time.Second)
+	got := []time.Duration{}
+	for attempt := 1; attempt <= 4; attempt++ {
+		got = append(got, exp(attempt, 0))
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("exponential delay %d: got %v, want %v", i+1, got[i], want[i])
+		}
+	}
+
+	if d := ConstantBackoff(time.Second)(10, time.Minute); d != time.Second {
+		t.Errorf("constant delay: got %v, want %v", d, time.Second)
+	}
+
+	jitter := DecorrelatedJitterBackoff(time.Second)
+	prev := time.Duration(0)
+	for attempt := 1; attempt <= 10; attempt++ {
+		d := jitter(attempt, prev)
+		if d < time.Second || d > max(time.Second, 3*prev) {
+			t.Errorf("decorrelated jitter delay %v is out of range [1s, %v]", d, max(time.Second, 3*prev))
+		}
+		prev = d
+	}
+
+	for range 10 {
+		d := ConstantBackoff(time.Second).WithJitter(0.1)(1, 0)
+		if d < 900*time.Millisecond || d > 1100*time.Millisecond {
+			t.Errorf("jittered delay %v is out of range [900ms, 1.1s]", d)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+
+	attempts := 0
+	err := Retry(context.Background(), RetryPolicy{Backoff: ConstantBackoff(0)}, func(context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Errorf("Retry: got %v after %d attempts, want nil after 3", err, attempts)
+	}
+}
+
+func TestRetryMaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	expected := errors.New("failed")
+	attempts := 0
+	v, err := RetryValue(context.Background(), RetryPolicy{Backoff: ConstantBackoff(0), MaxAttempts: 4}, func(context.Context) (int, error) {
+		attempts++
+		return attempts, expected
+	})
+	if err != expected || v != 4 || attempts != 4 {
+		t.Errorf("RetryValue: got %d, %v after %d attempts, want 4, %v after 4", v, err, attempts, expected)
+	}
+}
+
+func TestRetryRetryable(t *testing.T) {
+	t.Parallel()
+
+	permanent := errors.New("permanent")
+	attempts := 0
+	err := Retry(context.Background(), RetryPolicy{
+		Backoff:   ConstantBackoff(0),
+		Retryable: func(err error) bool { return err != permanent },
+	}, func(context.Context) error {
+		attempts++
+		if attempts == 2 {
+			return permanent
+		}
+		return errors.New("temporary")
+	})
+	if err != permanent || attempts != 2 {
+		t.Errorf("Retry: got %v after %d attempts, want %v after 2", err, attempts, permanent)
+	}
+}
+
+func TestRetryMaxElapsed(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if clock.Timers() > 0 {
+				clock.Advance(time.Second)
+			}
+			runtime.Gosched()
+		}
+	}()
+
+	attempts := 0
+	err := Retry(context.Background(), RetryPolicy{
+		Backoff:    ConstantBackoff(10 * time.Second),
+		MaxElapsed: 25 * time.Second,
+	}, func(context.Context) error {
+		attempts++
+		return errors.New("failed")
+	}, WithClock(clock))
+	if err == nil || attempts != 3 {
+		t.Errorf("Retry: got %v after %d attempts, want error after 3", err, attempts)
+	}
+}
+
+func TestRetryDefaultMaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	attempts := 0
+	err := Retry(context.Background(), RetryPolicy{Backoff: ConstantBackoff(0)}, func(context.Context) error {
+		attempts++
+		return errors.New("failed")
+	})
+	if err == nil || attempts != 5 {
+		t.Errorf("Retry: got %v after %d attempts, want error after 5", err, attempts)
+	}
+
+	attempts = 0
+	err = Retry(context.Background(), RetryPolicy{Backoff: ConstantBackoff(0), MaxAttempts: -1}, func(context.Context) error {
+		attempts++
+		if attempts < 10 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	if err != nil || attempts != 10 {
+		t.Errorf("Retry without limit: got %v after %d attempts, want nil after 10", err, attempts)
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	ctx, cancel := context.WithCancel(context.Background())
+	expected := errors.New("failed")
+	done := make(chan error)
+	go func() {
+		done <- Retry(ctx, RetryPolicy{Backoff: ConstantBackoff(time.Minute)}, func(context.Context) error {
+			return expected
+		}, WithClock(clock))
+	}()
+	for clock.Timers() == 0 {
+		runtime.Gosched()
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) || !errors.Is(err, expected) {
+		t.Errorf("Retry error: got %v, want %v with %v", err, context.Canceled, expected)
+	}
+}
+
+func TestRetryLoader(t *testing.T) {
+	t.Parallel()
+
+	p := NewTTLPointer[int](time.Minute)
+	attempts := 0
+	got, err := p.GetSet(RetryLoader(context.Background(), RetryPolicy{Backoff: ConstantBackoff(0)}, func() (*int, error) {
+		attempts++
+		if attempts < 2 {
+			return nil, errors.New("failed")
+		}
+		return ptr.Of(attempts), nil
+	}))
+	if err != nil || *got != 2 {
+		t.Errorf("GetSet with RetryLoader: got %v, %v, want 2, nil", got, err)
+	}
+}
+
+func TestRetryFunc(t *testing.T) {
+	t.Parallel()
+
+	failed := map[int]bool{}
+	var sum int
+	err := slice.Batch([]int{1, 2, 3, 4, 5}, 2, RetryFunc(context.Background(), RetryPolicy{Backoff: ConstantBackoff(0)}, func(batch []int) error {
+		if !failed[batch[0]] {
+			failed[batch[0]] = true
+			return errors.New("failed")
+		}
+		for _, v := range batch {
+			sum += v
+		}
+		return nil
+	}))
+	if err != nil || sum != 15 {
+		t.Errorf("Batch with RetryFunc: got sum %d, %v, want 15, nil", sum, err)
+	}
+}
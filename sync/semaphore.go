@@ -1,44 +1,320 @@
+// The waiter queue of Semaphore is derived from golang.org/x/sync/semaphore.
+//
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found at https://go.dev/LICENSE.
+
 // Package semaphore provides a semaphore implementation.
 package sync
 
-import "context"
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
 
 // Semaphore provides a way to bound concurrent access to a resource.
+// Tokens can be acquired one by one or in batches (weighted acquiring).
+// Waiters are served in FIFO order, so large requests are not starved by small ones.
+// Waiters with a higher priority are served first, see AcquirePriority.
 type Semaphore struct {
-	tokens chan struct{}
+	mu      sync.Mutex
+	size    int
+	cur     int
+	waiters list.List // of *semaphoreWaiter, promoted first, then by priority, then FIFO
+	// oversized is the number of waiters that request more tokens than the limit.
+	// They are skipped until the limit grows, so they do not block the others.
+	oversized int
+	opts      semaphoreOptions
+}
+
+// SemaphoreOption configures a Semaphore.
+type SemaphoreOption interface {
+	applySemaphore(*semaphoreOptions)
+}
+
+type semaphoreOptions struct {
+	clock             Clock
+	starvationTimeout time.Duration
+}
+
+type semaphoreOptionFunc func(*semaphoreOptions)
+
+func (f semaphoreOptionFunc) applySemaphore(o *semaphoreOptions) { f(o) }
+
+func (o ClockOption) applySemaphore(s *semaphoreOptions) { s.clock = o.clock }
+
+// WithStarvationTimeout makes a semaphore waiter that has waited for longer than d be served
+// before waiters of any priority, so a steady stream of high-priority work does not starve low-priority one.
+func WithStarvationTimeout(d time.Duration) SemaphoreOption {
+	return semaphoreOptionFunc(func(o *semaphoreOptions) {
+		o.starvationTimeout = d
+	})
 }
 
-// NewSemaphore creates a new semaphore with the specified number of tokens
-func NewSemaphore(n int) *Semaphore {
-	return &Semaphore{tokens: make(chan struct{}, n)}
+type semaphoreWaiter struct {
+	n         int
+	prio      int
+	since     time.Time
+	promoted  bool
+	oversized bool
+	ready     chan struct{}
+}
+
+// NewSemaphore creates a new semaphore with the specified number of tokens.
+// WithStarvationTimeout enables promoting long waiting low-priority requests, WithClock replaces the real time for it.
+func NewSemaphore(n int, opts ...SemaphoreOption) *Semaphore {
+	o := semaphoreOptions{clock: RealClock{}}
+	for _, opt := range opts {
+		opt.applySemaphore(&o)
+	}
+	return &Semaphore{size: n, opts: o}
 }
 
 // Acquire waits until a token is available, then acquires it
 func (s *Semaphore) Acquire(ctx context.Context) error {
+	return s.AcquireN(ctx, 1)
+}
+
+// AcquireN waits until n tokens are available, then acquires them all at once.
+// If n exceeds the limit, AcquireN waits until the limit grows enough, without blocking other callers.
+// On failure, returns ctx.Err() and leaves the semaphore unchanged.
+func (s *Semaphore) AcquireN(ctx context.Context, n int) error {
+	return s.AcquirePriorityN(ctx, n, 0)
+}
+
+// AcquirePriority waits until a token is available, then acquires it.
+// Waiters with a higher prio are served first, waiters with equal prio are served in FIFO order.
+// Acquire and AcquireN wait with zero priority.
+func (s *Semaphore) AcquirePriority(ctx context.Context, prio int) error {
+	return s.AcquirePriorityN(ctx, 1, prio)
+}
+
+// AcquirePriorityN waits until n tokens are available, then acquires them all at once, serving waiters by prio
+// as AcquirePriority does. On failure, returns ctx.Err() and leaves the semaphore unchanged.
+func (s *Semaphore) AcquirePriorityN(ctx context.Context, n int, prio int) error {
+	checkTokens(n)
+	done := ctx.Done()
+
+	s.mu.Lock()
 	select {
-	case s.tokens <- struct{}{}:
+	case <-done:
+		s.mu.Unlock()
+		return ctx.Err()
+	default:
+	}
+	if s.size-s.cur >= n && s.waiters.Len() == s.oversized {
+		s.cur += n
+		s.mu.Unlock()
 		return nil
-	case <-ctx.Done():
+	}
+
+	ready := make(chan struct{})
+	elem := s.enqueue(&semaphoreWaiter{n: n, prio: prio, ready: ready})
+	if s.size > s.cur {
+		// We may have overtaken a waiter that does not fit into the free tokens, while we do.
+		s.notifyWaiters()
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-done:
+		s.mu.Lock()
+		select {
+		case <-ready:
+			// The tokens were granted while ctx was being canceled. Give them back, as the caller gets an error.
+			s.cur -= n
+			s.notifyWaiters()
+		default:
+			s.remove(elem)
+			// The waiters behind us may fit into the free tokens now.
+			if s.size > s.cur {
+				s.notifyWaiters()
+			}
+		}
+		s.mu.Unlock()
 		return ctx.Err()
+
+	case <-ready:
+		// Both ready and done may have fired, and select picks at random. Prefer done for consistent results.
+		select {
+		case <-done:
+			s.ReleaseN(n)
+			return ctx.Err()
+		default:
+		}
+		return nil
 	}
 }
 
 // TryAcquire acquires the semaphore without blocking. On success, returns true.
 // On failure, returns false and leaves the semaphore unchanged.
 func (s *Semaphore) TryAcquire() bool {
-	select {
-	case s.tokens <- struct{}{}:
+	return s.TryAcquireN(1)
+}
+
+// TryAcquireN acquires n tokens without blocking. On success, returns true.
+// On failure, returns false and leaves the semaphore unchanged.
+func (s *Semaphore) TryAcquireN(n int) bool {
+	checkTokens(n)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size-s.cur >= n && s.waiters.Len() == s.oversized {
+		s.cur += n
 		return true
-	default:
-		return false
 	}
+	return false
 }
 
 // Release releases a token that was previously acquired.
 func (s *Semaphore) Release() {
-	select {
-	case <-s.tokens:
-	default:
-		panic("nothing to release, 0 tokens acquired")
+	s.ReleaseN(1)
+}
+
+// ReleaseN releases n tokens that were previously acquired.
+// It panics if more tokens are released than are held.
+func (s *Semaphore) ReleaseN(n int) {
+	checkTokens(n)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cur < n {
+		panic("nothing to release, released more tokens than acquired")
+	}
+	s.cur -= n
+	s.notifyWaiters()
+}
+
+// SetLimit changes the number of tokens of the semaphore.
+// Growing the limit wakes waiters immediately. Shrinking it never revokes acquired tokens:
+// new acquires block until enough holders release theirs to fit into the new limit.
+func (s *Semaphore) SetLimit(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.size = n
+	s.oversized = 0
+	for e := s.waiters.Front(); e != nil; e = e.Next() {
+		w := e.Value.(*semaphoreWaiter)
+		w.oversized = w.n > n
+		if w.oversized {
+			s.oversized++
+		}
+	}
+	s.notifyWaiters()
+}
+
+// Limit returns the current number of tokens of the semaphore.
+func (s *Semaphore) Limit() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.size
+}
+
+// InUse returns the number of acquired tokens.
+// It can exceed Limit for a while after the limit has been shrunk.
+func (s *Semaphore) InUse() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cur
+}
+
+// Waiting returns the number of callers blocked in acquiring.
+func (s *Semaphore) Waiting() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.waiters.Len()
+}
+
+// checkTokens panics if the number of tokens is negative, as it would silently create or destroy tokens.
+func checkTokens(n int) {
+	if n < 0 {
+		panic("negative number of tokens")
+	}
+}
+
+// enqueue inserts the waiter after the promoted waiters and the waiters with the same or higher priority.
+func (s *Semaphore) enqueue(w *semaphoreWaiter) *list.Element {
+	if s.opts.starvationTimeout > 0 {
+		w.since = s.opts.clock.Now()
+	}
+	if w.oversized = w.n > s.size; w.oversized {
+		s.oversized++
+	}
+	for e := s.waiters.Back(); e != nil; e = e.Prev() {
+		if prev := e.Value.(*semaphoreWaiter); prev.promoted || prev.prio >= w.prio {
+			return s.waiters.InsertAfter(w, e)
+		}
+	}
+	return s.waiters.PushFront(w)
+}
+
+// remove removes the waiter from the queue.
+func (s *Semaphore) remove(e *list.Element) {
+	if e.Value.(*semaphoreWaiter).oversized {
+		s.oversized--
+	}
+	s.waiters.Remove(e)
+}
+
+// promoteStarving moves waiters that have waited for longer than the starvation timeout to the front of the queue,
+// keeping the promoted waiters in the order of their arrival.
+func (s *Semaphore) promoteStarving() {
+	if s.opts.starvationTimeout <= 0 {
+		return
+	}
+	deadline := s.opts.clock.Now().Add(-s.opts.starvationTimeout)
+	var last *list.Element // the last promoted waiter
+	for e := s.waiters.Front(); e != nil; {
+		w := e.Value.(*semaphoreWaiter)
+		next := e.Next()
+		switch {
+		case w.promoted:
+			last = e
+		case w.since.Before(deadline) || w.since.Equal(deadline):
+			w.promoted = true
+			// Find the place among the promoted waiters by the arrival time.
+			at := last
+			for at != nil && at.Value.(*semaphoreWaiter).since.After(w.since) {
+				at = at.Prev()
+			}
+			if at == nil {
+				s.waiters.MoveToFront(e)
+			} else {
+				s.waiters.MoveAfter(e, at)
+			}
+			if last == nil || last == at {
+				last = e
+			}
+		}
+		e = next
+	}
+}
+
+// notifyWaiters wakes waiters in the queue order while there are enough tokens for the first of them.
+func (s *Semaphore) notifyWaiters() {
+	s.promoteStarving()
+	for next := s.waiters.Front(); next != nil; {
+		w := next.Value.(*semaphoreWaiter)
+		if w.oversized {
+			next = next.Next()
+			continue
+		}
+		if s.size-s.cur < w.n {
+			// Waiters behind it are not served even if they fit, otherwise a steady flow
+			// of small requests would starve the large one.
+			break
+		}
+
+		s.cur += w.n
+		e := next
+		next = next.Next()
+		s.remove(e)
+		close(w.ready)
 	}
 }
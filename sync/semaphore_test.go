@@ -101,3 +101,362 @@ func TestSemaphoreAcquire(t *testing.T) {
 		}
 	}
 }
+
+func HammerN(sem *Semaphore, n, loops int) {
+	for i := 0; i < loops; i++ {
+		sem.AcquireN(context.Background(), n)
+		time.Sleep(time.Duration(rand.Int63n(1000)) * time.Millisecond / 1000)
+		sem.ReleaseN(n)
+	}
+}
+
+func TestSemaphoreWeighted(t *testing.T) {
+	t.Parallel()
+
+	n := runtime.GOMAXPROCS(0)
+	loops := 1000 / n
+	sem := NewSemaphore(n)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			HammerN(sem, i+1, loops)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSemaphoreReleaseNPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("release of more tokens than acquired did not panic")
+		}
+	}()
+	sem := NewSemaphore(3)
+	sem.AcquireN(context.Background(), 2)
+	sem.ReleaseN(3)
+}
+
+func TestSemaphoreNegativePanic(t *testing.T) {
+	t.Parallel()
+
+	sem := NewSemaphore(1)
+	calls := []func(){
+		func() { sem.AcquireN(context.Background(), -1) },
+		func() { sem.TryAcquireN(-1) },
+		func() { sem.ReleaseN(-1) },
+	}
+	for i, call := range calls {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("calls[%d] with negative tokens did not panic", i)
+				}
+			}()
+			call()
+		}()
+	}
+	if got := sem.InUse(); got != 0 {
+		t.Errorf("InUse: got %d, want 0", got)
+	}
+}
+
+func TestSemaphoreTryAcquireN(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sem := NewSemaphore(5)
+	tries := []bool{}
+	sem.AcquireN(ctx, 2)
+	tries = append(tries, sem.TryAcquireN(3))
+	tries = append(tries, sem.TryAcquireN(1))
+
+	sem.ReleaseN(2)
+	tries = append(tries, sem.TryAcquireN(3))
+	tries = append(tries, sem.TryAcquireN(6))
+
+	want := []bool{true, false, false, false}
+	for i := range tries {
+		if tries[i] != want[i] {
+			t.Errorf("tries[%d]: got %t, want %t", i, tries[i], want[i])
+		}
+	}
+}
+
+func TestSemaphoreAcquireNCanceled(t *testing.T) {
+	t.Parallel()
+
+	sem := NewSemaphore(2)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sem.AcquireN(ctx, 1); err == nil {
+		t.Fatal("AcquireN with canceled context must return error")
+	}
+	if !sem.TryAcquireN(2) {
+		t.Fatal("canceled AcquireN must leave the semaphore unchanged")
+	}
+}
+
+func TestSemaphoreFIFO(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sem := NewSemaphore(3)
+	sem.AcquireN(ctx, 3)
+
+	// The large request waits first, so the small one must not overtake it.
+	large := make(chan struct{})
+	go func() {
+		sem.AcquireN(ctx, 3)
+		close(large)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	small := make(chan struct{})
+	go func() {
+		sem.AcquireN(ctx, 1)
+		close(small)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	sem.ReleaseN(2)
+	select {
+	case <-small:
+		t.Fatal("small request overtook the large one")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	sem.Release()
+	<-large
+	sem.ReleaseN(3)
+	<-small
+}
+
+func TestSemaphoreAcquireNCancelWakesWaiters(t *testing.T) {
+	t.Parallel()
+
+	sem := NewSemaphore(2)
+	sem.Acquire(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	blocked := make(chan error)
+	go func() { blocked <- sem.AcquireN(ctx, 2) }()
+	time.Sleep(10 * time.Millisecond)
+
+	next := make(chan struct{})
+	go func() {
+		sem.Acquire(context.Background())
+		close(next)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	if err := <-blocked; err == nil {
+		t.Fatal("canceled AcquireN must return error")
+	}
+	select {
+	case <-next:
+	case <-time.After(time.Second):
+		t.Fatal("waiter behind the canceled one was not woken")
+	}
+}
+
+func TestSemaphoreSetLimit(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sem := NewSemaphore(1)
+	sem.Acquire(ctx)
+
+	acquired := make(chan struct{})
+	go func() {
+		sem.AcquireN(ctx, 2)
+		close(acquired)
+	}()
+	for sem.Waiting() != 1 {
+		runtime.Gosched()
+	}
+
+	// Growing wakes the waiter right away.
+	sem.SetLimit(3)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("growing the limit did not wake the waiter")
+	}
+	if got := sem.InUse(); got != 3 {
+		t.Errorf("InUse: got %d, want 3", got)
+	}
+
+	// Shrinking keeps acquired tokens but blocks new acquires.
+	sem.SetLimit(2)
+	if got := sem.Limit(); got != 2 {
+		t.Errorf("Limit: got %d, want 2", got)
+	}
+	sem.Release()
+	if sem.TryAcquire() {
+		t.Error("TryAcquire succeeded while in use tokens reach the new limit")
+	}
+	sem.Release()
+	if !sem.TryAcquire() {
+		t.Error("TryAcquire failed while there are free tokens")
+	}
+	if got := sem.Waiting(); got != 0 {
+		t.Errorf("Waiting: got %d, want 0", got)
+	}
+}
+
+func HammerPriority(sem *Semaphore, loops int) {
+	for i := 0; i < loops; i++ {
+		sem.AcquirePriority(context.Background(), rand.Intn(3))
+		time.Sleep(time.Duration(rand.Int63n(1000)) * time.Millisecond / 1000)
+		sem.Release()
+	}
+}
+
+func TestSemaphorePriorityHammer(t *testing.T) {
+	t.Parallel()
+
+	n := runtime.GOMAXPROCS(0)
+	loops := 1000 / n
+	sem := NewSemaphore(max(n/2, 1), WithStarvationTimeout(time.Millisecond))
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			HammerPriority(sem, loops)
+		}()
+	}
+	wg.Wait()
+
+	if got := sem.InUse(); got != 0 {
+		t.Errorf("InUse: got %d, want 0", got)
+	}
+}
+
+// acquireInOrder makes waiters with the given priorities block on the full semaphore one after another,
+// then releases the token and returns the indexes of the waiters in the order they were served.
+func acquireInOrder(sem *Semaphore, prios []int, beforeEach func()) []int {
+	order := make(chan int, len(prios))
+	for i, prio := range prios {
+		if beforeEach != nil {
+			beforeEach()
+		}
+		go func() {
+			sem.AcquirePriority(context.Background(), prio)
+			order <- i
+			sem.Release()
+		}()
+		for sem.Waiting() != i+1 {
+			runtime.Gosched()
+		}
+	}
+	sem.Release()
+
+	served := make([]int, 0, len(prios))
+	for range prios {
+		served = append(served, <-order)
+	}
+	return served
+}
+
+func TestSemaphorePriority(t *testing.T) {
+	t.Parallel()
+
+	sem := NewSemaphore(1)
+	sem.Acquire(context.Background())
+	served := acquireInOrder(sem, []int{0, 5, 0, 5, 10}, nil)
+
+	want := []int{4, 1, 3, 0, 2}
+	for i := range served {
+		if served[i] != want[i] {
+			t.Errorf("served[%d]: got %d, want %d", i, served[i], want[i])
+		}
+	}
+}
+
+func TestSemaphorePriorityStarvation(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	sem := NewSemaphore(1, WithStarvationTimeout(time.Second), WithClock(clock))
+	sem.Acquire(context.Background())
+
+	// The first two waiters starve by the time the token is released, so they are served in the arrival order.
+	i := 0
+	served := acquireInOrder(sem, []int{0, 1, 2}, func() {
+		if i == 2 {
+			clock.Advance(2 * time.Second)
+		} else {
+			clock.Advance(time.Millisecond)
+		}
+		i++
+	})
+
+	want := []int{0, 1, 2}
+	for i := range served {
+		if served[i] != want[i] {
+			t.Errorf("served[%d]: got %d, want %d", i, served[i], want[i])
+		}
+	}
+}
+
+func TestSemaphorePriorityOvertakes(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sem := NewSemaphore(3)
+	sem.AcquireN(ctx, 2)
+
+	go sem.AcquireN(ctx, 3)
+	for sem.Waiting() != 1 {
+		runtime.Gosched()
+	}
+
+	// The free token fits the high-priority request that overtakes the large one.
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := sem.AcquirePriority(ctx, 1); err != nil {
+		t.Errorf("AcquirePriority returns error %v", err)
+	}
+}
+
+func TestSemaphoreOversized(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sem := NewSemaphore(2)
+	acquired := make(chan struct{})
+	go func() {
+		sem.AcquireN(ctx, 3)
+		close(acquired)
+	}()
+	for sem.Waiting() != 1 {
+		runtime.Gosched()
+	}
+
+	// The request that exceeds the limit does not block the others.
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := sem.Acquire(ctx); err != nil {
+		t.Errorf("Acquire returns error %v", err)
+	}
+	if !sem.TryAcquire() {
+		t.Error("TryAcquire failed while there are free tokens")
+	}
+
+	// It is served once the limit grows enough.
+	sem.SetLimit(5)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("growing the limit did not wake the oversized waiter")
+	}
+	if got := sem.InUse(); got != 5 {
+		t.Errorf("InUse: got %d, want 5", got)
+	}
+}
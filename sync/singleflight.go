@@ -0,0 +1,136 @@
+package sync
+
+import (
+	"context"
+	"sync"
+)
+
+// SingleFlight provides a duplicate call suppression mechanism.
+// Concurrent calls with the same key share the result of one execution of the function.
+// The zero value is ready to use.
+type SingleFlight[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*flightCall[V]
+}
+
+// FlightResult holds the results of SingleFlight.DoChan.
+type FlightResult[V any] struct {
+	Val    V
+	Err    error
+	Shared bool
+}
+
+type flightCall[V any] struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+	shared  bool
+	val     V
+	err     error
+}
+
+// Do executes and returns the results of the given function, making sure that only one execution
+// is in-flight for a given key at a time. If a duplicate comes in, the duplicate caller waits for
+// the original to complete and receives the same results. The return value shared reports whether
+// the result was given to multiple callers.
+//
+// The function gets a context that is not canceled while at least one caller is waiting for the result.
+// If ctx is done, the caller stops waiting and gets ctx.Err(), but the execution goes on for the others.
+// A panic in the function is returned as PanicError.
+func (g *SingleFlight[K, V]) Do(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) (v V, err error, shared bool) {
+	r := g.wait(ctx, key, g.join(ctx, key, fn))
+	return r.Val, r.Err, r.Shared
+}
+
+// DoChan is like Do but returns a channel that will receive the results when they are ready.
+func (g *SingleFlight[K, V]) DoChan(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) <-chan FlightResult[V] {
+	ch := make(chan FlightResult[V], 1)
+	c := g.join(ctx, key, fn)
+	go func() {
+		ch <- g.wait(ctx, key, c)
+	}()
+	return ch
+}
+
+// Forget tells the SingleFlight to forget about a key. Future calls to Do for this key
+// will call the function rather than waiting for an earlier call to complete.
+func (g *SingleFlight[K, V]) Forget(key K) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.calls, key)
+}
+
+// join returns the in-flight call for the key or starts a new one.
+func (g *SingleFlight[K, V]) join(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) *flightCall[V] {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if c, ok := g.calls[key]; ok {
+		c.waiters++
+		c.shared = true
+		return c
+	}
+
+	if g.calls == nil {
+		g.calls = make(map[K]*flightCall[V])
+	}
+	callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	c := &flightCall[V]{done: make(chan struct{}), cancel: cancel, waiters: 1}
+	g.calls[key] = c
+	go g.call(callCtx, key, c, fn)
+	return c
+}
+
+func (g *SingleFlight[K, V]) call(ctx context.Context, key K, c *flightCall[V], fn func(ctx context.Context) (V, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.err = newPanicError(r)
+		}
+
+		g.mu.Lock()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+
+		c.cancel()
+		close(c.done)
+	}()
+
+	c.val, c.err = fn(ctx)
+}
+
+// wait waits for the result of the call. If ctx is done, the caller leaves the call,
+// and the last leaving caller cancels it.
+func (g *SingleFlight[K, V]) wait(ctx context.Context, key K, c *flightCall[V]) FlightResult[V] {
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		select {
+		case <-c.done:
+		default:
+			g.leave(key, c)
+			return FlightResult[V]{Err: ctx.Err()}
+		}
+	}
+
+	g.mu.Lock()
+	shared := c.shared
+	g.mu.Unlock()
+	return FlightResult[V]{Val: c.val, Err: c.err, Shared: shared}
+}
+
+func (g *SingleFlight[K, V]) leave(key K, c *flightCall[V]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c.waiters--
+	if c.waiters > 0 {
+		return
+	}
+	c.cancel()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
@@ -0,0 +1,161 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSingleFlightDo(t *testing.T) {
+	t.Parallel()
+
+	var g SingleFlight[string, int]
+	v, err, shared := g.Do(context.Background(), "key", func(context.Context) (int, error) {
+		return 1, nil
+	})
+	if v != 1 || err != nil || shared {
+		t.Errorf("Do = %d, %v, %t; want 1, nil, false", v, err, shared)
+	}
+
+	expected := errors.New("failed")
+	_, err, _ = g.Do(context.Background(), "key", func(context.Context) (int, error) {
+		return 0, expected
+	})
+	if err != expected {
+		t.Errorf("Do error = %v; want %v", err, expected)
+	}
+}
+
+func TestSingleFlightDoDupSuppress(t *testing.T) {
+	t.Parallel()
+
+	var g SingleFlight[string, int]
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fn := func(context.Context) (int, error) {
+		calls.Add(1)
+		<-release
+		return 1, nil
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	var sharedCount atomic.Int32
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err, shared := g.Do(context.Background(), "key", fn)
+			if v != 1 || err != nil {
+				t.Errorf("Do = %d, %v; want 1, nil", v, err)
+			}
+			if shared {
+				sharedCount.Add(1)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("number of calls = %d; want 1", got)
+	}
+	if got := sharedCount.Load(); got != n {
+		t.Errorf("number of shared results = %d; want %d", got, n)
+	}
+}
+
+func TestSingleFlightDoWaiterCancel(t *testing.T) {
+	t.Parallel()
+
+	var g SingleFlight[string, int]
+	release := make(chan struct{})
+	callCanceled := make(chan bool, 1)
+	fn := func(ctx context.Context) (int, error) {
+		<-release
+		callCanceled <- ctx.Err() != nil
+		return 1, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := g.DoChan(ctx, "key", fn)
+	second := g.DoChan(context.Background(), "key", fn)
+
+	cancel()
+	if r := <-first; !errors.Is(r.Err, context.Canceled) {
+		t.Errorf("canceled waiter error = %v; want %v", r.Err, context.Canceled)
+	}
+
+	close(release)
+	if r := <-second; r.Val != 1 || r.Err != nil || !r.Shared {
+		t.Errorf("DoChan = %+v; want 1, nil, shared", r)
+	}
+	if <-callCanceled {
+		t.Error("shared call was canceled while another caller was waiting")
+	}
+}
+
+func TestSingleFlightDoAllWaitersCancel(t *testing.T) {
+	t.Parallel()
+
+	var g SingleFlight[string, int]
+	canceled := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	result := g.DoChan(ctx, "key", func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		close(canceled)
+		return 0, ctx.Err()
+	})
+
+	cancel()
+	<-result
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("call was not canceled after all callers gave up")
+	}
+
+	v, err, _ := g.Do(context.Background(), "key", func(context.Context) (int, error) { return 2, nil })
+	if v != 2 || err != nil {
+		t.Errorf("Do after cancellation = %d, %v; want 2, nil", v, err)
+	}
+}
+
+func TestSingleFlightForget(t *testing.T) {
+	t.Parallel()
+
+	var g SingleFlight[string, int]
+	release := make(chan struct{})
+	first := g.DoChan(context.Background(), "key", func(context.Context) (int, error) {
+		<-release
+		return 1, nil
+	})
+
+	g.Forget("key")
+	v, _, shared := g.Do(context.Background(), "key", func(context.Context) (int, error) { return 2, nil })
+	if v != 2 || shared {
+		t.Errorf("Do after Forget = %d, %t; want 2, false", v, shared)
+	}
+
+	close(release)
+	if r := <-first; r.Val != 1 {
+		t.Errorf("forgotten call = %d; want 1", r.Val)
+	}
+}
+
+func TestSingleFlightPanic(t *testing.T) {
+	t.Parallel()
+
+	var g SingleFlight[string, int]
+	_, err, _ := g.Do(context.Background(), "key", func(context.Context) (int, error) {
+		panic("boom")
+	})
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || panicErr.Value != "boom" {
+		t.Errorf("Do error = %v; want PanicError with boom", err)
+	}
+}
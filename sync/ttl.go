@@ -1,6 +1,7 @@
 package sync
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -8,15 +9,81 @@ import (
 
 // TTLPointer is a pointer to a value that will be updated periodically.
 type TTLPointer[T any] struct {
-	u       atomic.Pointer[T]
-	ttl     atomic.Int64 // time.Duration
-	updated atomic.Int64 // Unix time
-	mu      sync.Mutex
+	u          atomic.Pointer[T]
+	ttl        atomic.Int64 // time.Duration
+	updated    atomic.Int64 // Unix time in nanoseconds
+	mu         sync.Mutex
+	loading    *Future[*T] // load in flight, guarded by mu
+	refreshing atomic.Bool
+	opts       ttlOptions
+}
+
+// TTLOption configures a TTLPointer. TTL options also apply to the entries of TTLMap.
+type TTLOption interface {
+	TTLMapOption
+	applyTTL(*ttlOptions)
+}
+
+type ttlOptions struct {
+	clock                Clock
+	staleWhileRevalidate bool
+	maxStale             time.Duration
+	refreshAhead         time.Duration
+	onError              func(error)
+}
+
+type ttlOptionFunc func(*ttlOptions)
+
+func (f ttlOptionFunc) applyTTL(o *ttlOptions)       { f(o) }
+func (f ttlOptionFunc) applyTTLMap(o *ttlMapOptions) { f(&o.ttl) }
+
+func (o ClockOption) applyTTL(t *ttlOptions) { t.clock = o.clock }
+
+// handleError passes the error to the error handler if it is set.
+func (o *ttlOptions) handleError(err error) {
+	if o.onError != nil {
+		o.onError(err)
+	}
+}
+
+// WithStaleWhileRevalidate makes an expired value still be returned while one goroutine refreshes it in the background.
+// Values that have been expired for longer than maxStale are not returned, and callers block on loading again.
+// Zero maxStale means that staleness is not bounded.
+func WithStaleWhileRevalidate(maxStale time.Duration) TTLOption {
+	return ttlOptionFunc(func(o *ttlOptions) {
+		o.staleWhileRevalidate = true
+		o.maxStale = maxStale
+	})
+}
+
+// WithRefreshAhead makes a value be refreshed in the background once it is accessed within d before its expiry,
+// so callers never wait for loading while the value is in demand.
+func WithRefreshAhead(d time.Duration) TTLOption {
+	return ttlOptionFunc(func(o *ttlOptions) {
+		o.refreshAhead = d
+	})
+}
+
+// WithErrorHandler sets the handler of errors that cannot be returned to a caller, like errors of background refreshes.
+func WithErrorHandler(f func(err error)) TTLOption {
+	return ttlOptionFunc(func(o *ttlOptions) {
+		o.onError = f
+	})
 }
 
 // NewTTLPointer creates a new TTLPointer.
-func NewTTLPointer[T any](ttl time.Duration) *TTLPointer[T] {
-	p := &TTLPointer[T]{}
+// Options WithStaleWhileRevalidate, WithRefreshAhead and WithErrorHandler configure refreshing of the value in GetSet,
+// WithClock replaces the real time.
+func NewTTLPointer[T any](ttl time.Duration, opts ...TTLOption) *TTLPointer[T] {
+	o := ttlOptions{clock: RealClock{}}
+	for _, opt := range opts {
+		opt.applyTTL(&o)
+	}
+	return newTTLPointer[T](ttl, o)
+}
+
+func newTTLPointer[T any](ttl time.Duration, opts ttlOptions) *TTLPointer[T] {
+	p := &TTLPointer[T]{opts: opts}
 	p.ttl.Store(int64(ttl))
 	return p
 }
@@ -26,7 +93,7 @@ func (p *TTLPointer[T]) Get() *T {
 	if p.ttl.Load() == 0 {
 		return p.u.Load()
 	}
-	if time.Now().Sub(time.Unix(p.updated.Load(), 0)) > time.Duration(p.ttl.Load()) {
+	if p.age() > time.Duration(p.ttl.Load()) {
 		return nil
 	}
 	return p.u.Load()
@@ -35,7 +102,7 @@ func (p *TTLPointer[T]) Get() *T {
 // Set sets the value stored in the pointer.
 func (p *TTLPointer[T]) Set(t *T) {
 	p.u.Store(t)
-	p.updated.Store(time.Now().Unix())
+	p.updated.Store(p.clock().Now().UnixNano())
 }
 
 // SetTTL sets the TTL of the pointer.
@@ -44,24 +111,137 @@ func (p *TTLPointer[T]) SetTTL(ttl time.Duration) {
 }
 
 // GetSet returns the value stored in the pointer. If the value is nil, it will return the value of the getter function.
+// Concurrent callers share one call of the getter and its result, even if it fails. A panic in the getter is returned as PanicError.
+//
+// If the pointer was created with WithStaleWhileRevalidate, an expired value is returned
+// while one goroutine refreshes it in the background, until the value exceeds the max staleness.
+// If the pointer was created with WithRefreshAhead, the value accessed shortly before it expires is refreshed
+// in the background. Refreshes are driven by GetSet calls, there is no timer refreshing values nobody accesses.
+// Errors and panics of background refreshes are passed to the handler set by WithErrorHandler.
 func (p *TTLPointer[T]) GetSet(getter func() (*T, error)) (*T, error) {
-	v := p.Get()
-	if v != nil {
-		return v, nil
+	if v := p.u.Load(); v != nil {
+		ttl := time.Duration(p.ttl.Load())
+		age := p.age()
+		switch {
+		case ttl == 0:
+			return v, nil
+		case age <= ttl:
+			if p.opts.refreshAhead > 0 && age > ttl-p.opts.refreshAhead {
+				p.refresh(getter)
+			}
+			return v, nil
+		case p.opts.staleWhileRevalidate && (p.opts.maxStale == 0 || age <= ttl+p.opts.maxStale):
+			p.refresh(getter)
+			return v, nil
+		}
 	}
+
+	return p.load(getter, p.Get).Await(context.Background())
+}
+
+// getSetTouch is like GetSet without background refreshing, but also resets the age of the value.
+func (p *TTLPointer[T]) getSetTouch(getter func() (*T, error)) (*T, error) {
+	v, err := p.load(getter, p.Get).Await(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	// Recheck if the value is still nil.
-	v = p.Get()
-	if v != nil {
-		return v, nil
+	// The value may have been replaced meanwhile, then it is fresh already.
+	if p.u.Load() == v {
+		p.Set(v)
 	}
+	return v, nil
+}
 
-	var err error
-	if v, err = getter(); err != nil {
-		return nil, err
+// load returns the future of the value loaded by the getter, unless current returns a value under the lock.
+// If a load is in flight, it is joined instead, so the callers waiting at the same time share its result,
+// whether it is a value or an error. The getter is called without holding the lock, its panic is returned as PanicError.
+func (p *TTLPointer[T]) load(getter func() (*T, error), current func() *T) *Future[*T] {
+	p.mu.Lock()
+	if f := p.loading; f != nil {
+		p.mu.Unlock()
+		return f
 	}
-	p.Set(v)
-	return v, nil
+	if v := current(); v != nil {
+		p.mu.Unlock()
+		return Resolved(v)
+	}
+	f := newFuture[*T]()
+	p.loading = f
+	p.mu.Unlock()
+
+	v, err := protect(getter)
+
+	p.mu.Lock()
+	if err == nil {
+		p.Set(v)
+	}
+	p.loading = nil
+	p.mu.Unlock()
+
+	f.complete(v, err)
+	return f
+}
+
+// evictable reports whether the value can no longer be returned by GetSet without loading.
+// Values that may be served stale without a bound and values that have never been set are never evictable.
+func (p *TTLPointer[T]) evictable() bool {
+	ttl := time.Duration(p.ttl.Load())
+	if p.u.Load() == nil {
+		return false
+	}
+	if ttl == 0 {
+		return false
+	}
+	age := p.age()
+	if age <= ttl {
+		return false
+	}
+	return !p.opts.staleWhileRevalidate || (p.opts.maxStale != 0 && age > ttl+p.opts.maxStale)
+}
+
+// age returns the time elapsed since the last update.
+func (p *TTLPointer[T]) age() time.Duration {
+	return p.clock().Now().Sub(time.Unix(0, p.updated.Load()))
+}
+
+// clock returns the clock of the pointer. The zero TTLPointer uses the real time.
+func (p *TTLPointer[T]) clock() Clock {
+	if p.opts.clock == nil {
+		return RealClock{}
+	}
+	return p.opts.clock
+}
+
+// refresh updates the value in the background, unless another refresh is in progress.
+func (p *TTLPointer[T]) refresh(getter func() (*T, error)) {
+	if !p.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer p.refreshing.Store(false)
+
+		// The value may have been loaded by a blocked caller meanwhile, then it is not loaded again.
+		_, err := p.load(getter, func() *T {
+			if p.refreshable() {
+				return nil
+			}
+			return p.u.Load()
+		}).Await(context.Background())
+		if err != nil {
+			p.opts.handleError(err)
+		}
+	}()
+}
+
+// refreshable reports whether the value is expired or due to be refreshed ahead of its expiry.
+func (p *TTLPointer[T]) refreshable() bool {
+	ttl := time.Duration(p.ttl.Load())
+	if p.u.Load() == nil {
+		return true
+	}
+	return ttl != 0 && p.age() > ttl-p.opts.refreshAhead
 }
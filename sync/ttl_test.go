@@ -9,7 +9,8 @@ import (
 )
 
 func TestTTLPointerSet_Get(t *testing.T) {
-	p := NewTTLPointer[int](time.Second * 1)
+	clock := NewFakeClock(time.Now())
+	p := NewTTLPointer[int](time.Second*1, WithClock(clock))
 	expected := 1
 	p.Set(ptr.Of(expected))
 	switch got := p.Get(); {
@@ -18,12 +19,24 @@ func TestTTLPointerSet_Get(t *testing.T) {
 	case *got != expected:
 		t.Errorf("TTLPointer.Get() expected %d but got %d", expected, *got)
 	}
-	time.Sleep(time.Second * 2)
+	clock.Advance(time.Second * 2)
 	if got := p.Get(); got != nil {
 		t.Errorf("TTLPointer.Get() expected nil but got %d", *got)
 	}
 }
 
+func TestTTLPointerZero(t *testing.T) {
+	var p TTLPointer[int]
+	expected := 1
+	p.Set(ptr.Of(expected))
+	if got := p.Get(); got == nil || *got != expected {
+		t.Errorf("TTLPointer.Get() expected %d but got %v", expected, got)
+	}
+	if got, err := p.GetSet(func() (*int, error) { return ptr.Of(2), nil }); err != nil || *got != expected {
+		t.Errorf("TTLPointer.GetSet() expected %d but got %v, %v", expected, got, err)
+	}
+}
+
 func TestTTLPointerGetSet(t *testing.T) {
 	data := []struct {
 		Delay    time.Duration
@@ -46,10 +59,11 @@ func TestTTLPointerGetSet(t *testing.T) {
 			Expected: 3,
 		},
 	}
-	p := NewTTLPointer[int](time.Second * 1)
+	clock := NewFakeClock(time.Now())
+	p := NewTTLPointer[int](time.Second*1, WithClock(clock))
 	updaterCallsCount := 0
 	for _, data := range data {
-		time.Sleep(data.Delay)
+		clock.Advance(data.Delay)
 		switch got, err := p.GetSet(func() (*int, error) {
 			updaterCallsCount++
 			return ptr.Of(data.Value), nil
@@ -77,3 +91,137 @@ func TestTTLPointerGetSet_Error(t *testing.T) {
 		t.Errorf("TTLPointer.GetSet() expected nil but got %v", got)
 	}
 }
+
+func TestTTLPointerGetSet_StaleWhileRevalidate(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	p := NewTTLPointer[int](time.Second, WithStaleWhileRevalidate(0), WithClock(clock))
+	p.Set(ptr.Of(1))
+	clock.Advance(time.Second * 2)
+
+	release := make(chan struct{})
+	refreshed := make(chan struct{})
+	got, err := p.GetSet(func() (*int, error) {
+		defer close(refreshed)
+		<-release
+		return ptr.Of(2), nil
+	})
+	switch {
+	case err != nil:
+		t.Fatalf("TTLPointer.GetSet() returns error %s", err.Error())
+	case *got != 1:
+		t.Errorf("TTLPointer.GetSet() expected stale value 1 but got %d", *got)
+	}
+
+	close(release)
+	<-refreshed
+	got, _ = p.GetSet(func() (*int, error) { return ptr.Of(3), nil })
+	if *got != 2 {
+		t.Errorf("TTLPointer.GetSet() expected refreshed value 2 but got %d", *got)
+	}
+}
+
+func TestTTLPointerGetSet_MaxStale(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	p := NewTTLPointer[int](time.Second, WithStaleWhileRevalidate(time.Millisecond), WithClock(clock))
+	p.Set(ptr.Of(1))
+	clock.Advance(time.Second + 2*time.Millisecond)
+
+	got, err := p.GetSet(func() (*int, error) { return ptr.Of(2), nil })
+	switch {
+	case err != nil:
+		t.Fatalf("TTLPointer.GetSet() returns error %s", err.Error())
+	case *got != 2:
+		t.Errorf("TTLPointer.GetSet() expected loaded value 2 but got %d", *got)
+	}
+}
+
+func TestTTLPointerGetSet_RefreshAhead(t *testing.T) {
+	p := NewTTLPointer[int](time.Minute, WithRefreshAhead(time.Minute))
+	p.Set(ptr.Of(1))
+
+	refreshed := make(chan struct{})
+	got, _ := p.GetSet(func() (*int, error) {
+		defer close(refreshed)
+		return ptr.Of(2), nil
+	})
+	if *got != 1 {
+		t.Errorf("TTLPointer.GetSet() expected current value 1 but got %d", *got)
+	}
+	<-refreshed
+	for p.refreshing.Load() {
+		time.Sleep(time.Millisecond)
+	}
+	if got := p.Get(); *got != 2 {
+		t.Errorf("TTLPointer.Get() expected refreshed value 2 but got %d", *got)
+	}
+}
+
+func TestTTLPointerGetSet_RefreshError(t *testing.T) {
+	errs := make(chan error, 1)
+	p := NewTTLPointer[int](time.Minute, WithRefreshAhead(time.Minute), WithErrorHandler(func(err error) {
+		errs <- err
+	}))
+	p.Set(ptr.Of(1))
+
+	expected := errors.New("refresh failed")
+	got, err := p.GetSet(func() (*int, error) { return nil, expected })
+	switch {
+	case err != nil:
+		t.Fatalf("TTLPointer.GetSet() returns error %s", err.Error())
+	case *got != 1:
+		t.Errorf("TTLPointer.GetSet() expected current value 1 but got %d", *got)
+	}
+	if err := <-errs; err != expected {
+		t.Errorf("error handler expected %v but got %v", expected, err)
+	}
+}
+
+func TestTTLPointerGetSet_RefreshPanic(t *testing.T) {
+	errs := make(chan error, 1)
+	p := NewTTLPointer[int](time.Minute, WithRefreshAhead(time.Minute), WithErrorHandler(func(err error) {
+		errs <- err
+	}))
+	p.Set(ptr.Of(1))
+
+	got, err := p.GetSet(func() (*int, error) { panic("refresh failed") })
+	if err != nil || *got != 1 {
+		t.Errorf("TTLPointer.GetSet() expected current value 1 but got %v, %v", got, err)
+	}
+	var panicErr *PanicError
+	if err := <-errs; !errors.As(err, &panicErr) {
+		t.Errorf("error handler expected panic error but got %v", err)
+	}
+}
+
+func TestTTLPointerGetSet_RefreshFresh(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	p := NewTTLPointer[int](time.Minute, WithStaleWhileRevalidate(0), WithClock(clock))
+	p.Set(ptr.Of(1))
+	clock.Advance(time.Minute * 2)
+
+	// The value is reloaded by another caller before the background refresh takes the lock.
+	p.mu.Lock()
+	p.GetSet(func() (*int, error) { return ptr.Of(2), nil })
+	p.Set(ptr.Of(3))
+	p.mu.Unlock()
+	for p.refreshing.Load() {
+		time.Sleep(time.Millisecond)
+	}
+	if got := p.Get(); *got != 3 {
+		t.Errorf("TTLPointer.Get() expected fresh value 3 but got %d", *got)
+	}
+}
+
+func TestTTLPointerSet_GetMilliseconds(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	p := NewTTLPointer[int](time.Millisecond*10, WithClock(clock))
+	p.Set(ptr.Of(1))
+	clock.Advance(time.Millisecond * 9)
+	if got := p.Get(); got == nil {
+		t.Errorf("TTLPointer.Get() expected 1 but got nil")
+	}
+	clock.Advance(time.Millisecond * 2)
+	if got := p.Get(); got != nil {
+		t.Errorf("TTLPointer.Get() expected nil but got %d", *got)
+	}
+}
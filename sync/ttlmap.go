@@ -0,0 +1,208 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TTLMap is a concurrent map whose entries expire after the TTL.
+// Every entry behaves like TTLPointer, so the map accepts the same options.
+// Expired entries are evicted by a janitor goroutine, which runs until Close is called or the context is done.
+type TTLMap[K comparable, V any] struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[K]*ttlEntry[V]
+	opts    ttlOptions
+	done    chan struct{}
+	close   sync.Once
+}
+
+// TTLMapOption configures a TTLMap. All TTLOption options apply to the entries of the map.
+type TTLMapOption interface {
+	applyTTLMap(*ttlMapOptions)
+}
+
+type ttlMapOptions struct {
+	ttl             ttlOptions
+	cleanupInterval time.Duration
+}
+
+func (o ClockOption) applyTTLMap(m *ttlMapOptions)           { m.ttl.clock = o.clock }
+func (o CleanupIntervalOption) applyTTLMap(m *ttlMapOptions) { m.cleanupInterval = o.interval }
+
+// ttlEntry is an entry of TTLMap. The janitor does not evict entries that are in use,
+// so callers never write to entries that are no longer in the map.
+type ttlEntry[V any] struct {
+	*TTLPointer[V]
+	users atomic.Int32
+}
+
+// NewTTLMap creates a new TTLMap with the default TTL of entries.
+// The janitor evicts expired entries every TTL or every interval set by WithCleanupInterval.
+// If both of them are zero, the janitor is not started.
+// The options of NewTTLPointer apply to the entries, WithClock also applies to the janitor.
+func NewTTLMap[K comparable, V any](ctx context.Context, ttl time.Duration, opts ...TTLMapOption) *TTLMap[K, V] {
+	o := ttlMapOptions{ttl: ttlOptions{clock: RealClock{}}}
+	for _, opt := range opts {
+		opt.applyTTLMap(&o)
+	}
+	m := &TTLMap[K, V]{
+		ttl:     ttl,
+		entries: make(map[K]*ttlEntry[V]),
+		opts:    o.ttl,
+		done:    make(chan struct{}),
+	}
+	interval := ttl
+	if o.cleanupInterval != 0 {
+		interval = o.cleanupInterval
+	}
+	if interval > 0 {
+		go m.janitor(ctx, interval)
+	}
+	return m
+}
+
+// Get returns the value stored in the map for the key. If the entry is absent or expired, ok is false.
+func (m *TTLMap[K, V]) Get(key K) (value V, ok bool) {
+	m.mu.RLock()
+	e := m.entries[key]
+	m.mu.RUnlock()
+
+	if e == nil {
+		return value, false
+	}
+	if v := e.Get(); v != nil {
+		return *v, true
+	}
+	return value, false
+}
+
+// Set sets the value for the key with the default TTL.
+func (m *TTLMap[K, V]) Set(key K, value V) {
+	m.SetWithTTL(key, value, m.ttl)
+}
+
+// SetWithTTL sets the value for the key with the TTL that overrides the default one.
+func (m *TTLMap[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	e := m.acquire(key)
+	defer e.users.Add(-1)
+
+	e.SetTTL(ttl)
+	e.Set(&value)
+}
+
+// GetSet returns the value stored in the map for the key. If the entry is absent or expired,
+// it will return the value of the loader function. Concurrent loads of the same key are deduplicated, even failing ones.
+func (m *TTLMap[K, V]) GetSet(key K, loader func() (V, error)) (V, error) {
+	return m.getSet(key, loader, (*TTLPointer[V]).GetSet)
+}
+
+// getSetTouch is like GetSet, but also resets the expiry of the entry, so the entries in use do not expire.
+func (m *TTLMap[K, V]) getSetTouch(key K, loader func() (V, error)) (V, error) {
+	return m.getSet(key, loader, (*TTLPointer[V]).getSetTouch)
+}
+
+func (m *TTLMap[K, V]) getSet(key K, loader func() (V, error), getSet func(*TTLPointer[V], func() (*V, error)) (*V, error)) (V, error) {
+	e := m.acquire(key)
+	defer e.users.Add(-1)
+
+	v, err := getSet(e.TTLPointer, func() (*V, error) {
+		v, err := loader()
+		if err != nil {
+			return nil, err
+		}
+		return &v, nil
+	})
+	if err != nil {
+		m.removeUnset(key, e)
+		var zero V
+		return zero, err
+	}
+	return *v, nil
+}
+
+// removeUnset deletes the entry of a failed load, unless it has been set or other callers use it.
+func (m *TTLMap[K, V]) removeUnset(key K, e *ttlEntry[V]) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.entries[key] == e && e.users.Load() == 1 && e.u.Load() == nil {
+		delete(m.entries, key)
+	}
+}
+
+// Delete deletes the value for the key.
+func (m *TTLMap[K, V]) Delete(key K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+}
+
+// Len returns the number of entries in the map, including expired ones that are not evicted yet.
+func (m *TTLMap[K, V]) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.entries)
+}
+
+// Close stops the janitor.
+func (m *TTLMap[K, V]) Close() {
+	m.close.Do(func() { close(m.done) })
+}
+
+// acquire returns the entry for the key, creating it if it is absent, and marks it as in use.
+// The caller must decrement the users of the entry when done.
+func (m *TTLMap[K, V]) acquire(key K) *ttlEntry[V] {
+	m.mu.RLock()
+	e := m.entries[key]
+	if e != nil {
+		e.users.Add(1)
+	}
+	m.mu.RUnlock()
+	if e != nil {
+		return e
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e = m.entries[key]; e == nil {
+		e = &ttlEntry[V]{TTLPointer: newTTLPointer[V](m.ttl, m.opts)}
+		m.entries[key] = e
+	}
+	e.users.Add(1)
+	return e
+}
+
+func (m *TTLMap[K, V]) janitor(ctx context.Context, interval time.Duration) {
+	timer := m.opts.clock.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.done:
+			return
+		case <-timer.C():
+			m.evictExpired()
+			timer.Reset(interval)
+		}
+	}
+}
+
+// evictExpired deletes expired entries. Entries that are in use, like being set or loaded, are kept.
+func (m *TTLMap[K, V]) evictExpired() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, e := range m.entries {
+		if e.users.Load() == 0 && e.evictable() {
+			delete(m.entries, key)
+		}
+	}
+}
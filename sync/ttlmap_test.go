@@ -0,0 +1,162 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTTLMapSet_Get(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	m := NewTTLMap[string, int](context.Background(), time.Minute, WithClock(clock))
+	defer m.Close()
+
+	if _, ok := m.Get("a"); ok {
+		t.Errorf("TTLMap.Get() expected absent value")
+	}
+	m.Set("a", 1)
+	m.SetWithTTL("b", 2, time.Second)
+	if got, ok := m.Get("a"); !ok || got != 1 {
+		t.Errorf("TTLMap.Get() expected 1 but got %d, %t", got, ok)
+	}
+	if got, ok := m.Get("b"); !ok || got != 2 {
+		t.Errorf("TTLMap.Get() expected 2 but got %d, %t", got, ok)
+	}
+
+	clock.Advance(time.Second * 2)
+	if got, ok := m.Get("b"); ok {
+		t.Errorf("TTLMap.Get() expected expired value but got %d", got)
+	}
+	if got, ok := m.Get("a"); !ok || got != 1 {
+		t.Errorf("TTLMap.Get() expected 1 but got %d, %t", got, ok)
+	}
+
+	m.evictExpired()
+	if got := m.Len(); got != 1 {
+		t.Errorf("TTLMap.Len() expected 1 after eviction but got %d", got)
+	}
+	m.Delete("a")
+	if got := m.Len(); got != 0 {
+		t.Errorf("TTLMap.Len() expected 0 after deletion but got %d", got)
+	}
+}
+
+func TestTTLMapGetSet(t *testing.T) {
+	m := NewTTLMap[string, int](context.Background(), time.Minute)
+	defer m.Close()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := m.GetSet("a", func() (int, error) {
+				calls.Add(1)
+				<-release
+				return 1, nil
+			})
+			if err != nil || got != 1 {
+				t.Errorf("TTLMap.GetSet() expected 1 but got %d, %v", got, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected 1 call of the loader but got %d", got)
+	}
+
+	_, err := m.GetSet("b", func() (int, error) { return 0, errors.New("") })
+	if err == nil {
+		t.Errorf("TTLMap.GetSet() expected error but got nil")
+	}
+}
+
+func TestTTLMapGetSetError(t *testing.T) {
+	m := NewTTLMap[string, int](context.Background(), time.Minute)
+	defer m.Close()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := m.GetSet("a", func() (int, error) {
+				calls.Add(1)
+				<-release
+				return 0, errors.New("load failed")
+			})
+			if err == nil {
+				t.Errorf("TTLMap.GetSet() expected error but got nil")
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected 1 call of the failing loader but got %d", got)
+	}
+	if got := m.Len(); got != 0 {
+		t.Errorf("expected no entries after the failed load but got %d", got)
+	}
+}
+
+func TestTTLMapJanitor(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := NewFakeClock(time.Now())
+	m := NewTTLMap[string, int](ctx, time.Second, WithCleanupInterval(100*time.Millisecond), WithClock(clock))
+
+	m.Set("a", 1)
+	for clock.Timers() == 0 {
+		runtime.Gosched()
+	}
+	clock.Advance(time.Second * 2)
+	for clock.Timers() == 0 {
+		runtime.Gosched()
+	}
+	if got := m.Len(); got != 0 {
+		t.Errorf("TTLMap.Len() expected 0 after eviction but got %d", got)
+	}
+}
+
+func TestTTLMapEvictInUse(t *testing.T) {
+	m := NewTTLMap[string, int](context.Background(), time.Minute)
+	defer m.Close()
+
+	// The janitor runs between creating the entry and setting it.
+	e := m.acquire("a")
+	m.evictExpired()
+	v := 1
+	e.Set(&v)
+	e.users.Add(-1)
+	if got, ok := m.Get("a"); !ok || got != 1 {
+		t.Errorf("TTLMap.Get() expected 1 but got %d, %t", got, ok)
+	}
+
+	// Entries that have never been set are not evicted.
+	m.acquire("b").users.Add(-1)
+	m.evictExpired()
+	if got := m.Len(); got != 2 {
+		t.Errorf("TTLMap.Len() expected 2 after eviction but got %d", got)
+	}
+	m.Delete("b")
+
+	// Entries of failed loads are removed by the loader.
+	m.GetSet("c", func() (int, error) { return 0, errors.New("") })
+	if got := m.Len(); got != 1 {
+		t.Errorf("TTLMap.Len() expected 1 after failed load but got %d", got)
+	}
+}
@@ -0,0 +1,76 @@
+package sync
+
+import "sync/atomic"
+
+// Value is a typed atomic value. Unlike atomic.Value, it holds values of any type, including structs,
+// and its zero value holds the zero value of T.
+// Each store allocates a new copy of the value, so it suits rarely changed values, like configs.
+type Value[T any] struct {
+	p atomic.Pointer[T]
+}
+
+// NewValue creates a new Value holding v.
+func NewValue[T any](v T) *Value[T] {
+	var val Value[T]
+	val.p.Store(&v)
+	return &val
+}
+
+// Load returns the current value.
+func (v *Value[T]) Load() T {
+	if p := v.p.Load(); p != nil {
+		return *p
+	}
+	var zero T
+	return zero
+}
+
+// Store sets the value to val.
+func (v *Value[T]) Store(val T) {
+	v.p.Store(&val)
+}
+
+// Swap sets the value to val and returns the previous value.
+func (v *Value[T]) Swap(val T) (old T) {
+	if p := v.p.Swap(&val); p != nil {
+		return *p
+	}
+	return old
+}
+
+// CompareAndSwap sets the value to new if the current value is equal to old by the equal function.
+// If equal is nil, the values are compared with ==, which panics if T is not comparable.
+func (v *Value[T]) CompareAndSwap(old, new T, equal func(a, b T) bool) bool {
+	if equal == nil {
+		equal = func(a, b T) bool { return any(a) == any(b) }
+	}
+	for {
+		p := v.p.Load()
+		var cur T
+		if p != nil {
+			cur = *p
+		}
+		if !equal(cur, old) {
+			return false
+		}
+		if v.p.CompareAndSwap(p, &new) {
+			return true
+		}
+	}
+}
+
+// Update atomically replaces the value with f applied to it and returns the new value.
+// f can be called several times under contention, so it must be free of side effects.
+func (v *Value[T]) Update(f func(T) T) T {
+	for {
+		p := v.p.Load()
+		var cur T
+		if p != nil {
+			cur = *p
+		}
+		next := f(cur)
+		if v.p.CompareAndSwap(p, &next) {
+			return next
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package sync
+
+import (
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		name  string
+		hosts []string
+	}
+	var v Value[config]
+	if got := v.Load(); got.name != "" || got.hosts != nil {
+		t.Errorf("Load of zero value: got %v, want zero", got)
+	}
+
+	v.Store(config{name: "a"})
+	if got := v.Swap(config{name: "b", hosts: []string{"h"}}); got.name != "a" {
+		t.Errorf("Swap: got %q, want %q", got.name, "a")
+	}
+
+	sameName := func(a, b config) bool { return a.name == b.name }
+	if v.CompareAndSwap(config{name: "a"}, config{name: "c"}, sameName) {
+		t.Error("CompareAndSwap with a different old value succeeded")
+	}
+	if !v.CompareAndSwap(config{name: "b"}, config{name: "c"}, sameName) {
+		t.Error("CompareAndSwap with an equal old value failed")
+	}
+	if got := v.Load(); got.name != "c" {
+		t.Errorf("Load: got %q, want %q", got.name, "c")
+	}
+
+	got := v.Update(func(c config) config {
+		c.hosts = append(slices.Clone(c.hosts), "h")
+		return c
+	})
+	if want := []string{"h"}; got.name != "c" || !slices.Equal(got.hosts, want) {
+		t.Errorf("Update: got %v, want %v", got, config{name: "c", hosts: want})
+	}
+}
+
+func TestValueCompareAndSwapComparable(t *testing.T) {
+	t.Parallel()
+
+	var v Value[int]
+	if !v.CompareAndSwap(0, 1, nil) {
+		t.Error("CompareAndSwap of zero value failed")
+	}
+	if v.CompareAndSwap(0, 2, nil) {
+		t.Error("CompareAndSwap with a different old value succeeded")
+	}
+	if got := NewValue(5).Load(); got != 5 {
+		t.Errorf("Load: got %d, want 5", got)
+	}
+}
+
+func HammerValue(v *Value[int], loops int) {
+	for i := 0; i < loops; i++ {
+		if i%2 == 0 {
+			v.Update(func(n int) int { return n + 1 })
+			continue
+		}
+		for {
+			old := v.Load()
+			if v.CompareAndSwap(old, old+1, nil) {
+				break
+			}
+		}
+	}
+}
+
+func TestValueHammer(t *testing.T) {
+	t.Parallel()
+
+	n := runtime.GOMAXPROCS(0)
+	loops := 10000 / n
+	var v Value[int]
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			HammerValue(&v, loops)
+		}()
+	}
+	wg.Wait()
+
+	if got := v.Load(); got != n*loops {
+		t.Errorf("value: got %d, want %d", got, n*loops)
+	}
+}
@@ -0,0 +1,120 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrPoolClosed is returned by submitting to a WorkerPool that is shut down.
+var ErrPoolClosed = errors.New("worker pool is closed")
+
+// WorkerPool runs a function over submitted inputs with bounded concurrency.
+// A panic in the function is recovered and returned as PanicError.
+type WorkerPool[In, Out any] struct {
+	fn  func(ctx context.Context, in In) (Out, error)
+	sem *Semaphore
+
+	mu      sync.RWMutex
+	closed  bool
+	closing context.Context
+	close   context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewWorkerPool creates a new WorkerPool that runs at most limit functions at once.
+func NewWorkerPool[In, Out any](limit int, fn func(ctx context.Context, in In) (Out, error)) *WorkerPool[In, Out] {
+	return NewWorkerPoolWithSemaphore(NewSemaphore(limit), fn)
+}
+
+// NewWorkerPoolWithSemaphore creates a new WorkerPool whose concurrency is limited by the semaphore.
+// The semaphore can be shared with other pools or resized at runtime.
+func NewWorkerPoolWithSemaphore[In, Out any](sem *Semaphore, fn func(ctx context.Context, in In) (Out, error)) *WorkerPool[In, Out] {
+	closing, close := context.WithCancel(context.Background())
+	return &WorkerPool[In, Out]{fn: fn, sem: sem, closing: closing, close: close}
+}
+
+// Submit waits until a worker is available and runs the function over the input with ctx.
+// It returns the future of the result, or an error if ctx is done first or the pool is shut down.
+func (p *WorkerPool[In, Out]) Submit(ctx context.Context, in In) (*Future[Out], error) {
+	acquireCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(p.closing, cancel)
+	defer stop()
+
+	if err := p.sem.Acquire(acquireCtx); err != nil {
+		if p.closing.Err() != nil {
+			return nil, ErrPoolClosed
+		}
+		return nil, err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		p.sem.Release()
+		return nil, ErrPoolClosed
+	}
+	p.wg.Add(1)
+
+	f := newFuture[Out]()
+	go func() {
+		defer p.wg.Done()
+		defer p.sem.Release()
+
+		f.complete(protect(func() (Out, error) { return p.fn(ctx, in) }))
+	}()
+	return f, nil
+}
+
+// Map runs the function over all items and returns the results in input order.
+// If some runs fail, it returns the first error in input order along with all results.
+func (p *WorkerPool[In, Out]) Map(ctx context.Context, items []In) ([]Out, error) {
+	futures := make([]*Future[Out], 0, len(items))
+	var submitErr error
+	for _, in := range items {
+		f, err := p.Submit(ctx, in)
+		if err != nil {
+			submitErr = err
+			break
+		}
+		futures = append(futures, f)
+	}
+
+	results := make([]Out, len(items))
+	var firstErr error
+	for i, f := range futures {
+		<-f.Done()
+		results[i] = f.val
+		if f.err != nil && firstErr == nil {
+			firstErr = f.err
+		}
+	}
+	if firstErr != nil {
+		return results, firstErr
+	}
+	return results, submitErr
+}
+
+// Shutdown stops accepting new inputs, including the ones waiting for a worker, and waits until all submitted ones are processed.
+// If ctx is done first, it returns ctx.Err(), and the remaining runs go on in the background.
+func (p *WorkerPool[In, Out]) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	p.close()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,129 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrencyMeter measures the maximum number of concurrent calls, like of tasks limited by a semaphore.
+type concurrencyMeter struct {
+	running, max atomic.Int32
+}
+
+// run is a call that takes a millisecond.
+func (m *concurrencyMeter) run() {
+	n := m.running.Add(1)
+	for {
+		cur := m.max.Load()
+		if n <= cur || m.max.CompareAndSwap(cur, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	m.running.Add(-1)
+}
+
+func TestWorkerPoolLimit(t *testing.T) {
+	t.Parallel()
+
+	const limit = 3
+	var meter concurrencyMeter
+	p := NewWorkerPool(limit, func(_ context.Context, in int) (int, error) {
+		meter.run()
+		return in * 2, nil
+	})
+
+	items := make([]int, 50)
+	for i := range items {
+		items[i] = i
+	}
+	results, err := p.Map(context.Background(), items)
+	if err != nil {
+		t.Fatalf("Map returns error %v", err)
+	}
+	for i, got := range results {
+		if got != i*2 {
+			t.Errorf("results[%d] = %d; want %d", i, got, i*2)
+		}
+	}
+	if got := meter.max.Load(); got > limit {
+		t.Errorf("max running workers = %d; want at most %d", got, limit)
+	}
+}
+
+func TestWorkerPoolMapError(t *testing.T) {
+	t.Parallel()
+
+	expected := errors.New("odd")
+	p := NewWorkerPoolWithSemaphore(NewSemaphore(2), func(_ context.Context, in int) (int, error) {
+		if in%2 == 1 {
+			return 0, expected
+		}
+		return in, nil
+	})
+	results, err := p.Map(context.Background(), []int{0, 1, 2, 3})
+	if err != expected {
+		t.Errorf("Map error = %v; want %v", err, expected)
+	}
+	if results[2] != 2 {
+		t.Errorf("results[2] = %d; want 2", results[2])
+	}
+}
+
+func TestWorkerPoolPanic(t *testing.T) {
+	t.Parallel()
+
+	p := NewWorkerPool(1, func(context.Context, int) (int, error) {
+		panic("boom")
+	})
+	f, err := p.Submit(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Submit returns error %v", err)
+	}
+	var panicErr *PanicError
+	if _, err := f.Await(context.Background()); !errors.As(err, &panicErr) {
+		t.Errorf("Await error = %v; want PanicError", err)
+	}
+
+	// The worker must be released after the panic.
+	if _, err := p.Submit(context.Background(), 2); err != nil {
+		t.Errorf("Submit after panic returns error %v", err)
+	}
+}
+
+func TestWorkerPoolShutdown(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	var processed atomic.Int32
+	p := NewWorkerPool(2, func(context.Context, int) (int, error) {
+		<-release
+		processed.Add(1)
+		return 0, nil
+	})
+	for i := range 2 {
+		if _, err := p.Submit(context.Background(), i); err != nil {
+			t.Fatalf("Submit returns error %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown error = %v; want %v", err, context.DeadlineExceeded)
+	}
+	if _, err := p.Submit(context.Background(), 3); err != ErrPoolClosed {
+		t.Errorf("Submit after Shutdown error = %v; want %v", err, ErrPoolClosed)
+	}
+
+	close(release)
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown returns error %v", err)
+	}
+	if got := processed.Load(); got != 2 {
+		t.Errorf("processed inputs = %d; want 2", got)
+	}
+}